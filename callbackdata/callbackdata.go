@@ -0,0 +1,107 @@
+// Package callbackdata keeps inline button callback data within the 64 bytes allowed by Telegram.
+//
+// Payloads that don't fit are kept in an overflow store on the server and the button carries a short hash key instead.
+package callbackdata
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// MaxLength is the maximum length of the callback_data of an inline button in bytes.
+const MaxLength = 64
+
+// overflowMarker starts the payload of callback data that carries an overflow store key
+const overflowMarker = "#"
+
+var (
+	// ErrTooLong is returned for callback data longer than MaxLength bytes.
+	ErrTooLong = errors.New("callbackdata: callback data is longer than 64 bytes")
+	// ErrExpired is returned when the overflow store no longer has the payload of a button.
+	ErrExpired = errors.New("callbackdata: callback data expired")
+)
+
+/*
+Validate returns ErrTooLong if the callback data doesn't fit into an inline button.
+*/
+func Validate(data string) error {
+	if len(data) > MaxLength {
+		return fmt.Errorf("%w: %q has %d bytes", ErrTooLong, data, len(data))
+	}
+	return nil
+}
+
+// Encoder builds the callback data of a widget from its prefix and a payload.
+type Encoder struct {
+	store  Store
+	signer *Signer
+}
+
+/*
+NewEncoder creates an encoder keeping long payloads in the store.
+Without a store, Encode returns ErrTooLong for callback data that doesn't fit.
+*/
+func NewEncoder(store Store) *Encoder {
+	return &Encoder{store: store}
+}
+
+/*
+WithSigner signs the encoded callback data, Decode rejects data with a wrong signature.
+The signature takes SignatureLength of the 64 bytes.
+*/
+func (e *Encoder) WithSigner(signer *Signer) *Encoder {
+	e.signer = signer
+	return e
+}
+
+/*
+Encode returns prefix + payload, or prefix + a hash key of the payload if it is too long.
+Without a store, payloads starting with the overflow marker "#" are sent as they are.
+*/
+func (e *Encoder) Encode(prefix, payload string) (string, error) {
+	if e == nil {
+		return prefix + payload, Validate(prefix + payload)
+	}
+
+	data := e.signer.Sign(prefix + payload)
+	if len(data) <= MaxLength && !strings.HasPrefix(payload, overflowMarker) {
+		return data, nil
+	}
+	if e.store == nil {
+		if err := Validate(data); err != nil {
+			return "", err
+		}
+		return data, nil
+	}
+
+	key := Key(payload)
+	data = e.signer.Sign(prefix + overflowMarker + key)
+	if err := Validate(data); err != nil {
+		return "", err
+	}
+	if err := e.store.Put(key, payload); err != nil {
+		return "", fmt.Errorf("callbackdata: store payload: %w", err)
+	}
+	return data, nil
+}
+
+/*
+Decode returns the payload of callback data created by Encode.
+*/
+func (e *Encoder) Decode(prefix, data string) (string, error) {
+	if e == nil {
+		return strings.TrimPrefix(data, prefix), nil
+	}
+
+	data, err := e.signer.Verify(data)
+	if err != nil {
+		return "", err
+	}
+	payload := strings.TrimPrefix(data, prefix)
+	if !strings.HasPrefix(payload, overflowMarker) || e.store == nil {
+		return payload, nil
+	}
+
+	return e.store.Get(strings.TrimPrefix(payload, overflowMarker))
+}
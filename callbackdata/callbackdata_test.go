@@ -0,0 +1,103 @@
+package callbackdata
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodeShort(t *testing.T) {
+	e := NewEncoder(nil)
+
+	data, err := e.Encode("pfx", "next")
+	assert.NoError(t, err)
+	assert.Equal(t, "pfxnext", data)
+
+	payload, err := e.Decode("pfx", data)
+	assert.NoError(t, err)
+	assert.Equal(t, "next", payload)
+}
+
+func TestEncodeTooLong(t *testing.T) {
+	long := "remove_filter_" + strings.Repeat("k", MaxLength)
+
+	_, err := NewEncoder(nil).Encode("pfx", long)
+	assert.ErrorIs(t, err, ErrTooLong)
+
+	store := NewMemoryStore()
+	e := NewEncoder(store)
+	data, err := e.Encode("pfx", long)
+	assert.NoError(t, err)
+	assert.LessOrEqual(t, len(data), MaxLength)
+
+	again, err := e.Encode("pfx", long)
+	assert.NoError(t, err)
+	assert.Equal(t, data, again, "the same payload gets the same key")
+
+	payload, err := e.Decode("pfx", data)
+	assert.NoError(t, err)
+	assert.Equal(t, long, payload)
+
+	_, err = NewEncoder(NewMemoryStore()).Decode("pfx", data)
+	assert.ErrorIs(t, err, ErrExpired)
+}
+
+func TestMemoryStoreEviction(t *testing.T) {
+	store := NewMemoryStore().WithMaxEntries(2)
+	store.Put("a", "1")
+	store.Put("b", "2")
+	_, err := store.Get("a")
+	assert.NoError(t, err)
+
+	// b is the least recently used
+	store.Put("c", "3")
+	_, err = store.Get("b")
+	assert.ErrorIs(t, err, ErrExpired)
+	payload, err := store.Get("a")
+	assert.NoError(t, err)
+	assert.Equal(t, "1", payload)
+
+	store.Put("c", "3")
+	assert.Equal(t, 2, store.order.Len(), "the same payload is stored once")
+}
+
+func TestEncodeMarker(t *testing.T) {
+	e := NewEncoder(NewMemoryStore())
+
+	// payloads looking like a store key are stored too, so they decode unchanged
+	data, err := e.Encode("pfx", "#tag")
+	assert.NoError(t, err)
+	payload, err := e.Decode("pfx", data)
+	assert.NoError(t, err)
+	assert.Equal(t, "#tag", payload)
+}
+
+func TestSigner(t *testing.T) {
+	assert.Nil(t, NewSigner(nil), "an empty key doesn't sign")
+
+	s := NewSigner([]byte("secret"))
+	data := s.Sign("pfx3")
+	assert.Len(t, data, len("pfx3")+SignatureLength)
+
+	verified, err := s.Verify(data)
+	assert.NoError(t, err)
+	assert.Equal(t, "pfx3", verified)
+
+	tampered := "pfx4" + data[len("pfx3"):]
+	_, err = s.Verify(tampered)
+	assert.ErrorIs(t, err, ErrInvalidSignature)
+	_, err = NewSigner([]byte("other")).Verify(data)
+	assert.ErrorIs(t, err, ErrInvalidSignature)
+	_, err = s.Verify("short")
+	assert.ErrorIs(t, err, ErrInvalidSignature)
+
+	e := NewEncoder(NewMemoryStore()).WithSigner(s)
+	long := strings.Repeat("k", MaxLength)
+	encoded, err := e.Encode("pfx", long)
+	assert.NoError(t, err)
+	assert.LessOrEqual(t, len(encoded), MaxLength)
+	payload, err := e.Decode("pfx", encoded)
+	assert.NoError(t, err)
+	assert.Equal(t, long, payload)
+}
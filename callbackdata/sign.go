@@ -0,0 +1,73 @@
+package callbackdata
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+)
+
+// SignatureLength is the number of characters a signature adds to the callback data.
+const SignatureLength = 11
+
+// InvalidButtonText is the answer to a button with invalid or tampered callback data.
+const InvalidButtonText = "This button is invalid or has expired"
+
+// ErrInvalidSignature is returned for callback data with a missing or wrong signature.
+var ErrInvalidSignature = errors.New("callbackdata: invalid signature")
+
+// Signer appends an HMAC of the callback data, so crafted callback queries are rejected.
+// A nil Signer leaves the callback data unchanged.
+type Signer struct {
+	key []byte
+}
+
+/*
+NewSigner creates a signer with the secret key. Use the same key across restarts to keep sent buttons working.
+It returns nil for an empty key, so the callback data isn't signed.
+*/
+func NewSigner(key []byte) *Signer {
+	if len(key) == 0 {
+		return nil
+	}
+	return &Signer{key: key}
+}
+
+// Sign returns the data followed by its signature.
+func (s *Signer) Sign(data string) string {
+	if s == nil {
+		return data
+	}
+	return data + s.signature(data)
+}
+
+// Verify checks the signature at the end of the data and returns the data without it.
+func (s *Signer) Verify(data string) (string, error) {
+	if s == nil {
+		return data, nil
+	}
+	if len(data) < SignatureLength {
+		return "", ErrInvalidSignature
+	}
+
+	data, signature := data[:len(data)-SignatureLength], data[len(data)-SignatureLength:]
+	if !hmac.Equal([]byte(signature), []byte(s.signature(data))) {
+		return "", ErrInvalidSignature
+	}
+	return data, nil
+}
+
+// Overhead returns the number of characters Sign adds.
+func (s *Signer) Overhead() int {
+	if s == nil {
+		return 0
+	}
+	return SignatureLength
+}
+
+// signature is the first 64 bits of the HMAC-SHA256 of the data.
+func (s *Signer) signature(data string) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(data))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:8])
+}
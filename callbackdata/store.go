@@ -0,0 +1,102 @@
+package callbackdata
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/base64"
+	"sync"
+)
+
+// keyLength is the length of an overflow store key, 72 bits of the payload hash
+const keyLength = 12
+
+// Store keeps the payloads that don't fit into callback data.
+// Implement it on top of a shared database to keep long buttons working across restarts and instances.
+type Store interface {
+	// Put stores the payload under the key
+	Put(key string, payload string) error
+	// Get returns the payload stored under the key, or ErrExpired
+	Get(key string) (string, error)
+}
+
+/*
+Key returns the overflow store key of a payload, a URL safe hash of keyLength characters.
+The same payload always gets the same key, so re-rendering a button doesn't grow the store.
+*/
+func Key(payload string) string {
+	sum := sha256.Sum256([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(sum[:])[:keyLength]
+}
+
+// DefaultMemoryStoreSize is the number of payloads a MemoryStore keeps, see WithMaxEntries
+const DefaultMemoryStoreSize = 10000
+
+/*
+MemoryStore is a Store in memory, it is safe for concurrent use.
+It keeps up to DefaultMemoryStoreSize payloads, the least recently used are evicted
+and their buttons answer ErrExpired.
+*/
+type MemoryStore struct {
+	mu         sync.Mutex
+	maxEntries int
+	payloads   map[string]*list.Element
+	order      *list.List
+}
+
+type memoryEntry struct {
+	key     string
+	payload string
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		maxEntries: DefaultMemoryStoreSize,
+		payloads:   make(map[string]*list.Element),
+		order:      list.New(),
+	}
+}
+
+// WithMaxEntries sets the number of payloads kept, zero keeps all of them.
+func (s *MemoryStore) WithMaxEntries(maxEntries int) *MemoryStore {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.maxEntries = maxEntries
+	s.evict()
+	return s
+}
+
+func (s *MemoryStore) Put(key string, payload string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if elem, ok := s.payloads[key]; ok {
+		elem.Value.(*memoryEntry).payload = payload
+		s.order.MoveToFront(elem)
+		return nil
+	}
+	s.payloads[key] = s.order.PushFront(&memoryEntry{key: key, payload: payload})
+	s.evict()
+	return nil
+}
+
+func (s *MemoryStore) Get(key string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	elem, ok := s.payloads[key]
+	if !ok {
+		return "", ErrExpired
+	}
+	s.order.MoveToFront(elem)
+	return elem.Value.(*memoryEntry).payload, nil
+}
+
+// evict removes the least recently used payloads over the size limit
+func (s *MemoryStore) evict() {
+	for s.maxEntries > 0 && s.order.Len() > s.maxEntries {
+		oldest := s.order.Back()
+		s.order.Remove(oldest)
+		delete(s.payloads, oldest.Value.(*memoryEntry).key)
+	}
+}
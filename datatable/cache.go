@@ -0,0 +1,211 @@
+package datatable
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+const (
+	REFRESH = "🔄 Refresh"
+
+	cbCmdRefresh = "refresh" // Reloads the current page, bypassing the page cache
+)
+
+// pageCache is a least recently used cache of data handler results with a time to live.
+// It is safe for concurrent use, so pages can be prefetched in the background.
+type pageCache struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	maxEntries int
+	entries    map[string]*list.Element
+	order      *list.List
+	now        func() time.Time
+	generation uint64 // Incremented by clear, results fetched before are dropped by put
+}
+
+type pageCacheEntry struct {
+	key     string
+	result  DataResult
+	expires time.Time
+}
+
+func newPageCache(ttl time.Duration, maxEntries int) *pageCache {
+	return &pageCache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+		now:        time.Now,
+	}
+}
+
+// get returns the cached result for the key if it hasn't expired.
+func (c *pageCache) get(key string) (DataResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return DataResult{}, false
+	}
+	entry := elem.Value.(*pageCacheEntry)
+	if c.ttl > 0 && c.now().After(entry.expires) {
+		c.order.Remove(elem)
+		delete(c.entries, key)
+		return DataResult{}, false
+	}
+	c.order.MoveToFront(elem)
+	return entry.result, true
+}
+
+// contains reports whether the key is cached, without touching its recency.
+func (c *pageCache) contains(key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	return ok && (c.ttl <= 0 || !c.now().After(elem.Value.(*pageCacheEntry).expires))
+}
+
+// gen returns the current generation, taken before a fetch and passed to put.
+func (c *pageCache) gen() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.generation
+}
+
+// put stores the result fetched in the generation and evicts the least recently used entries over the size limit.
+// A result fetched before the last clear is dropped, so an invalidated page isn't cached again.
+func (c *pageCache) put(key string, result DataResult, generation uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if generation != c.generation {
+		return
+	}
+
+	expires := c.now().Add(c.ttl)
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*pageCacheEntry)
+		entry.result = result
+		entry.expires = expires
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&pageCacheEntry{key: key, result: result, expires: expires})
+	for c.maxEntries > 0 && c.order.Len() > c.maxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*pageCacheEntry).key)
+	}
+}
+
+// clear removes all entries and starts a new generation.
+func (c *pageCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	c.entries = make(map[string]*list.Element)
+	c.order.Init()
+}
+
+func (c *pageCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// pageCacheKey encodes the filter, including page size, page number and cursor, independent of map order.
+func pageCacheKey(filter map[string]interface{}) string {
+	keys := make([]string, 0, len(filter))
+	for key, value := range filter {
+		if value != nil {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	for _, key := range keys {
+		fmt.Fprintf(&sb, "%q=%#v;", key, filter[key])
+	}
+	return sb.String()
+}
+
+/*
+InvalidateCache removes all cached pages, so the next render calls the data handler again.
+Call it after changing the data behind the table.
+*/
+func (d *DataTable) InvalidateCache() {
+	if d.cache != nil {
+		d.cache.clear()
+	}
+}
+
+// fetchPage returns the page for the filter from the cache, or calls the data handler and caches the result.
+func (d *DataTable) fetchPage(ctx context.Context, b *bot.Bot, pageSize, pageNum int, filter map[string]interface{}) DataResult {
+	if d.cache == nil {
+		return d.dataHandler(ctx, b, pageSize, pageNum, filter)
+	}
+
+	key := pageCacheKey(filter)
+	if result, ok := d.cache.get(key); ok {
+		fmt.Println("[datatable.fetchPage] cache hit, page:", pageNum)
+		return result
+	}
+
+	generation := d.cache.gen()
+	result := d.dataHandler(ctx, b, pageSize, pageNum, filter)
+	if result.Err == nil {
+		d.cache.put(key, result, generation)
+	}
+	return result
+}
+
+// prefetchNextPage loads the page after the shown one into the cache in the background.
+func (d *DataTable) prefetchNextPage(ctx context.Context, b *bot.Bot, filter map[string]interface{}) {
+	if d.cache == nil || !d.prefetch || d.err != nil {
+		return
+	}
+
+	next := copyFilter(filter)
+	pageNum := next["pageNum"].(int64)
+	if d.cursorPagination {
+		if !d.cursor.hasNext() {
+			return
+		}
+		next[CursorKey] = d.cursor.next
+	} else if pageNum >= d.pagesCount {
+		return
+	}
+	next["pageNum"] = pageNum + 1
+
+	if d.cache.contains(pageCacheKey(next)) {
+		return
+	}
+
+	// the prefetch outlives the update being handled
+	ctx = context.WithoutCancel(ctx)
+	pageSize := int(next["pageSize"].(int64))
+	go func() {
+		fmt.Println("[datatable.prefetchNextPage] prefetch page:", pageNum+1)
+		d.fetchPage(ctx, b, pageSize, int(pageNum+1), next)
+	}()
+}
+
+// handleRefresh reloads the current page from the data handler
+func (d *DataTable) handleRefresh(ctx context.Context, b *bot.Bot, mes models.MaybeInaccessibleMessage) {
+	fmt.Println("[datatable.handleRefresh] refresh")
+	d.InvalidateCache()
+	d.refresh(ctx, b)
+}
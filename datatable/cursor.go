@@ -0,0 +1,125 @@
+package datatable
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/jkevinp/tgui/button"
+)
+
+// CursorKey is the filter key holding the opaque cursor of the current page in cursor pagination mode.
+// It is absent on the first page.
+const CursorKey = "cursor"
+
+/*
+NewCursorDataResult creates a DataResult for cursor pagination.
+An empty prevCursor or nextCursor means there is no previous or next page.
+*/
+func NewCursorDataResult(text string, replyMarkup [][]button.Button, prevCursor, nextCursor string) DataResult {
+	return DataResult{
+		Text:        text,
+		ReplyMarkup: replyMarkup,
+		PrevCursor:  prevCursor,
+		NextCursor:  nextCursor,
+	}
+}
+
+/*
+WithHasMore returns a copy of the DataResult that tells whether NextCursor leads to more rows.
+Without it, the Next button is shown whenever NextCursor is set.
+*/
+func (r DataResult) WithHasMore(hasMore bool) DataResult {
+	r.HasMore = &hasMore
+	return r
+}
+
+// cursorState keeps the cursors of the shown page.
+// history holds the cursors of the pages before it, for handlers that don't return a previous cursor.
+type cursorState struct {
+	next    string
+	prev    string
+	hasMore *bool
+	history []string
+}
+
+func (c *cursorState) hasNext() bool {
+	if c.next == "" {
+		return false
+	}
+	return c.hasMore == nil || *c.hasMore
+}
+
+func (c *cursorState) hasPrev() bool {
+	return c.prev != "" || len(c.history) > 0
+}
+
+// update stores the cursors returned by the data handler.
+func (c *cursorState) update(result DataResult) {
+	c.next = result.NextCursor
+	c.prev = result.PrevCursor
+	c.hasMore = result.HasMore
+}
+
+// currentCursor returns the cursor of the shown page, "" on the first page.
+func (d *DataTable) currentCursor() string {
+	cursor, _ := d.currentFilter[CursorKey].(string)
+	return cursor
+}
+
+func (d *DataTable) setCursor(cursor string) {
+	if cursor == "" {
+		delete(d.currentFilter, CursorKey)
+		return
+	}
+	d.currentFilter[CursorKey] = cursor
+}
+
+// nextCursorPage moves to the page after the shown one.
+func (d *DataTable) nextCursorPage() bool {
+	if !d.cursor.hasNext() {
+		return false
+	}
+	d.cursor.history = append(d.cursor.history, d.currentCursor())
+	d.setCursor(d.cursor.next)
+	d.currentFilter["pageNum"] = d.currentFilter["pageNum"].(int64) + 1
+	return true
+}
+
+// prevCursorPage moves to the page before the shown one, using the previous cursor returned by
+// the data handler or the remembered cursor of the page before.
+func (d *DataTable) prevCursorPage() bool {
+	if !d.cursor.hasPrev() {
+		return false
+	}
+	prev := d.cursor.prev
+	if len(d.cursor.history) > 0 {
+		last := d.cursor.history[len(d.cursor.history)-1]
+		d.cursor.history = d.cursor.history[:len(d.cursor.history)-1]
+		if prev == "" {
+			prev = last
+		}
+	}
+	d.setCursor(prev)
+	if pageNum := d.currentFilter["pageNum"].(int64); pageNum > 1 {
+		d.currentFilter["pageNum"] = pageNum - 1
+	}
+	return true
+}
+
+// handleNextCursorPage processes navigation to the next page in cursor pagination mode
+func (d *DataTable) handleNextCursorPage(ctx context.Context, b *bot.Bot, mes models.MaybeInaccessibleMessage) {
+	fmt.Println("[datatable.handleNextCursorPage] next cursor:", d.cursor.next)
+	if d.nextCursorPage() {
+		d.refresh(ctx, b)
+	}
+}
+
+// handlePreviousCursorPage processes navigation to the previous page in cursor pagination mode
+func (d *DataTable) handlePreviousCursorPage(ctx context.Context, b *bot.Bot, mes models.MaybeInaccessibleMessage) {
+	fmt.Println("[datatable.handlePreviousCursorPage] prev cursor:", d.cursor.prev)
+	if d.prevCursorPage() {
+		d.refresh(ctx, b)
+	}
+}
@@ -7,11 +7,13 @@ import (
 	"log"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/jkevinp/tgui/button"
+	"github.com/jkevinp/tgui/callbackdata"
 	"github.com/jkevinp/tgui/helper"
-	"github.com/jkevinp/tgui/keyboard/inline"
 	"github.com/jkevinp/tgui/questionaire"
+	"github.com/jkevinp/tgui/router"
 
 	"github.com/go-telegram/bot"
 	"github.com/go-telegram/bot/models"
@@ -28,6 +30,9 @@ const (
 	FILTER_BY = "Filter by"
 	CANCEL    = "⬅️ Cancel"
 
+	FILTER_PROMPT = "Enter value for %s"
+	FILTER_CHIP   = "🗑 %s: %v"
+
 	LASTPAGE  = "%d ⏭️"
 	FIRSTPAGE = "%d ⏮️ "
 
@@ -74,6 +79,40 @@ type DataTable struct {
 
 	b          *bot.Bot
 	pagesCount int64
+
+	rows        []Row
+	selection   *selection
+	bulkActions []bulkAction
+
+	callbackHandlerID string
+	clickHandlers     []button.Button // Caller supplied buttons of the current render, see addButtons
+	detailHandler     detailHandlerFunc
+	detail            *detailState
+	quickSearch       bool
+	exportFormats     []ExportFormat
+	exportHandler     exportHandlerFunc
+
+	cursorPagination bool
+	cursor           cursorState
+
+	cache    *pageCache
+	prefetch bool
+
+	timeout  time.Duration
+	err      error
+	lastGood *pageState
+
+	labels Labels
+	layout Layout
+
+	name string
+
+	collapseText       string
+	filterQuestionaire *questionaire.Questionaire
+
+	router *router.Router
+	codec  *callbackdata.Encoder
+	signer *callbackdata.Signer // Signs the share tokens, nil without a signing key
 }
 
 // DataTableBuilder provides a fluent interface for building DataTable instances
@@ -85,6 +124,26 @@ type DataTableBuilder struct {
 	filterKeys          []string
 	onError             OnErrorHandler
 	onCancelHandler     func()
+	selectable          bool
+	bulkActions         []bulkAction
+	detailHandler       detailHandlerFunc
+	quickSearch         bool
+	exportFormats       []ExportFormat
+	exportHandler       exportHandlerFunc
+	cursorPagination    bool
+	pageCache           bool
+	cacheTTL            time.Duration
+	cacheSize           int
+	prefetch            bool
+	timeout             time.Duration
+	labels              map[string]Labels
+	locale              string
+	layout              Layout
+	name                string
+	collapseText        string
+	router              *router.Router
+	overflowStore       callbackdata.Store
+	signingKey          []byte
 }
 
 // NewBuilder creates a new DataTableBuilder with the required bot instance.
@@ -140,6 +199,163 @@ func (dtb *DataTableBuilder) WithOnCancelHandler(handler func()) *DataTableBuild
 	return dtb
 }
 
+// WithSelection enables row selection.
+// Rows returned in DataResult.Rows get a checkbox toggle and the selection is kept across pages.
+func (dtb *DataTableBuilder) WithSelection() *DataTableBuilder {
+	dtb.selectable = true
+	return dtb
+}
+
+// WithBulkAction adds a bulk action button shown while rows are selected.
+// It enables row selection. If handler is nil, it will be ignored.
+func (dtb *DataTableBuilder) WithBulkAction(text string, handler BulkActionHandler) *DataTableBuilder {
+	if handler != nil {
+		dtb.selectable = true
+		dtb.bulkActions = append(dtb.bulkActions, bulkAction{text: text, handler: handler})
+	}
+	return dtb
+}
+
+// WithDetailHandler sets the handler rendering the detail view of a row.
+// Rows open their detail view when tapped, and DetailButton can be used in DataResult.ReplyMarkup.
+func (dtb *DataTableBuilder) WithDetailHandler(handler detailHandlerFunc) *DataTableBuilder {
+	dtb.detailHandler = handler
+	return dtb
+}
+
+// WithQuickSearch enables quick search: any text sent while the table is shown becomes a full-text query.
+// The query is passed to the data handler as filter[SearchQueryKey].
+// The manager's HandleMessage must be registered with the bot to receive the text.
+func (dtb *DataTableBuilder) WithQuickSearch(manager *questionaire.Manager) *DataTableBuilder {
+	if manager != nil {
+		dtb.questionaireManager = manager
+	}
+	dtb.quickSearch = true
+	return dtb
+}
+
+// WithExport adds an Export control offering the given formats, or all formats if none are given.
+// Without an export handler, the data handler is re-run over all pages and DataResult.Records are exported.
+func (dtb *DataTableBuilder) WithExport(formats ...ExportFormat) *DataTableBuilder {
+	if len(formats) == 0 {
+		formats = []ExportFormat{ExportCSV, ExportJSON, ExportXLSX}
+	}
+	dtb.exportFormats = formats
+	return dtb
+}
+
+// WithExportHandler sets a dedicated handler writing all records matching the current filters.
+// It enables the Export control with all formats if WithExport wasn't called.
+func (dtb *DataTableBuilder) WithExportHandler(handler exportHandlerFunc) *DataTableBuilder {
+	dtb.exportHandler = handler
+	if handler != nil && len(dtb.exportFormats) == 0 {
+		dtb.WithExport()
+	}
+	return dtb
+}
+
+// WithCursorPagination switches to cursor (keyset) pagination.
+// The data handler returns the cursors of the neighbouring pages with NewCursorDataResult instead of a pages count,
+// and receives the cursor of the requested page as filter[CursorKey]. Only Prev and Next are shown.
+func (dtb *DataTableBuilder) WithCursorPagination() *DataTableBuilder {
+	dtb.cursorPagination = true
+	return dtb
+}
+
+// WithPageCache caches the results of the data handler per filters and page,
+// for ttl and up to maxEntries pages. A zero ttl or maxEntries means no limit.
+// A 🔄 Refresh control reloads the current page, and InvalidateCache drops all cached pages.
+func (dtb *DataTableBuilder) WithPageCache(ttl time.Duration, maxEntries int) *DataTableBuilder {
+	dtb.pageCache = true
+	dtb.cacheTTL = ttl
+	dtb.cacheSize = maxEntries
+	return dtb
+}
+
+// WithPrefetch loads the next page into the page cache in the background while the current one is shown.
+// It requires WithPageCache, and the data handler must be safe for concurrent use.
+func (dtb *DataTableBuilder) WithPrefetch() *DataTableBuilder {
+	dtb.prefetch = true
+	return dtb
+}
+
+// WithTimeout sets how long the data handler may run before the error view is shown.
+// The handler's context is cancelled after the timeout. If timeout is not positive, it will be ignored.
+func (dtb *DataTableBuilder) WithTimeout(timeout time.Duration) *DataTableBuilder {
+	if timeout > 0 {
+		dtb.timeout = timeout
+	}
+	return dtb
+}
+
+// WithLabels sets the control labels for a locale. Labels for the empty locale replace the defaults for all locales.
+// Empty fields fall back to the default labels.
+func (dtb *DataTableBuilder) WithLabels(locale string, labels Labels) *DataTableBuilder {
+	if dtb.labels == nil {
+		dtb.labels = make(map[string]Labels)
+	}
+	dtb.labels[locale] = labels
+	return dtb
+}
+
+// WithLocale selects the labels set with WithLabels for the locale, e.g. the user's language code.
+// Unknown locales use the default labels.
+func (dtb *DataTableBuilder) WithLocale(locale string) *DataTableBuilder {
+	dtb.locale = locale
+	return dtb
+}
+
+// WithLayout sets the page button window and which control rows are shown in which order.
+func (dtb *DataTableBuilder) WithLayout(layout Layout) *DataTableBuilder {
+	dtb.layout = layout
+	return dtb
+}
+
+// resolveLabels returns the labels of the selected locale, falling back to the default labels.
+func (dtb *DataTableBuilder) resolveLabels() Labels {
+	labels := DefaultLabels()
+	if base, ok := dtb.labels[""]; ok {
+		labels = base.withDefaults(labels)
+	}
+	if localized, ok := dtb.labels[dtb.locale]; ok && dtb.locale != "" {
+		labels = localized.withDefaults(labels)
+	}
+	return labels
+}
+
+// WithName names the table, e.g. "orders". The name identifies the table in share tokens and deep links,
+// and may only contain up to 32 letters, digits and hyphens.
+func (dtb *DataTableBuilder) WithName(name string) *DataTableBuilder {
+	dtb.name = name
+	return dtb
+}
+
+// WithCollapseOnClose replaces the table message with text when the table is closed, instead of deleting it.
+func (dtb *DataTableBuilder) WithCollapseOnClose(text string) *DataTableBuilder {
+	dtb.collapseText = text
+	return dtb
+}
+
+// WithRouter dispatches the table's callbacks through the router instead of registering its own bot handler.
+func (dtb *DataTableBuilder) WithRouter(r *router.Router) *DataTableBuilder {
+	dtb.router = r
+	return dtb
+}
+
+// WithOverflowStore keeps callback data longer than 64 bytes, e.g. long filter keys or row IDs, in the store
+// and sends a short hash key instead.
+func (dtb *DataTableBuilder) WithOverflowStore(store callbackdata.Store) *DataTableBuilder {
+	dtb.overflowStore = store
+	return dtb
+}
+
+// WithSigningKey signs the callback data and the share tokens with the key, clicks with tampered data are answered
+// as invalid and tampered tokens are rejected. The signature takes 11 of the 64 bytes of callback data or payload.
+func (dtb *DataTableBuilder) WithSigningKey(key []byte) *DataTableBuilder {
+	dtb.signingKey = key
+	return dtb
+}
+
 // Build validates the configuration and constructs the DataTable instance.
 // It returns an error if any required fields are missing or invalid.
 func (dtb *DataTableBuilder) Build() (*DataTable, error) {
@@ -153,10 +369,32 @@ func (dtb *DataTableBuilder) Build() (*DataTable, error) {
 	if dtb.itemsPerPage <= 0 {
 		return nil, errors.New("datatable: ItemsPerPage must be positive")
 	}
+	if dtb.quickSearch && dtb.questionaireManager == nil {
+		return nil, errors.New("datatable: QuickSearch requires a questionaire manager")
+	}
+	if dtb.prefetch && !dtb.pageCache {
+		return nil, errors.New("datatable: Prefetch requires a page cache")
+	}
+	if dtb.name != "" && !tableNamePattern.MatchString(dtb.name) {
+		return nil, errors.New("datatable: Name must be up to 32 letters, digits or hyphens")
+	}
 
 	// Construction - using the same logic as the original New() function
 	prefix := "dt" + bot.RandomString(14)
 	fmt.Println("new datatable", prefix)
+	labels := dtb.resolveLabels()
+
+	codec := callbackdata.NewEncoder(dtb.overflowStore)
+	if dtb.signingKey != nil {
+		codec.WithSigner(callbackdata.NewSigner(dtb.signingKey))
+	}
+	for _, filterKey := range dtb.filterKeys {
+		for _, command := range []string{cbPfxSelectFilterKey + filterKey, cbPfxRemoveFilter + filterKey} {
+			if _, err := codec.Encode(prefix, command); err != nil {
+				return nil, fmt.Errorf("datatable: filter key %q is too long for callback data, use a shorter key or WithOverflowStore: %w", filterKey, err)
+			}
+		}
+	}
 
 	dt := &DataTable{
 		b:                   dtb.bot,
@@ -167,16 +405,38 @@ func (dtb *DataTableBuilder) Build() (*DataTable, error) {
 		filterKeys:          dtb.filterKeys,
 		onCancelHandler:     dtb.onCancelHandler,
 		currentFilter:       make(map[string]interface{}),
+		bulkActions:         dtb.bulkActions,
+		detailHandler:       dtb.detailHandler,
+		quickSearch:         dtb.quickSearch,
+		exportFormats:       dtb.exportFormats,
+		exportHandler:       dtb.exportHandler,
+		cursorPagination:    dtb.cursorPagination,
+		prefetch:            dtb.prefetch,
+		timeout:             dtb.timeout,
+		labels:              labels,
+		layout:              dtb.layout.withDefaults(),
+		name:                dtb.name,
+		collapseText:        dtb.collapseText,
+		router:              dtb.router,
+		codec:               codec,
+		signer:              callbackdata.NewSigner(dtb.signingKey),
 		// Initialize control buttons
-		CtrlBack:   button.Button{Text: BACK, CallbackData: cbCmdBack},
-		CtrlNext:   button.Button{Text: NEXT, CallbackData: cbCmdNext},
-		CtrlClose:  button.Button{Text: CLOSE, CallbackData: cbCmdClose},
-		CtrlFilter: button.Button{Text: FILTER, CallbackData: cbCmdFilter},
+		CtrlBack:   button.Button{Text: labels.Back, CallbackData: cbCmdBack},
+		CtrlNext:   button.Button{Text: labels.Next, CallbackData: cbCmdNext},
+		CtrlClose:  button.Button{Text: labels.Close, CallbackData: cbCmdClose},
+		CtrlFilter: button.Button{Text: labels.Filter, CallbackData: cbCmdFilter},
 	}
 
 	dt.currentFilter["pageSize"] = int64(dtb.itemsPerPage)
 	dt.currentFilter["pageNum"] = int64(1)
 
+	if dtb.selectable {
+		dt.selection = newSelection()
+	}
+	if dtb.pageCache {
+		dt.cache = newPageCache(dtb.cacheTTL, dtb.cacheSize)
+	}
+
 	// Build filter buttons if filterKeys are provided
 	if len(dt.filterKeys) > 0 {
 		filterMenu := button.NewBuilder()
@@ -184,13 +444,13 @@ func (dtb *DataTableBuilder) Build() (*DataTable, error) {
 			fmt.Println("[datatable] adding filter:", filterKey)
 			filterMenu.Row().Add(button.Button{
 				Text:         filterKey,
-				CallbackData: dt.prefix + cbPfxSelectFilterKey + filterKey,
+				CallbackData: cbPfxSelectFilterKey + filterKey,
 				OnClick:      dt.nagivateCallback,
 			})
 		}
 		filterMenu.Row().Add(button.New(
-			CANCEL,
-			dt.prefix+cbCmdCancelFilterMenu,
+			dt.labels.Cancel,
+			cbCmdCancelFilterMenu,
 			dt.nagivateCallback,
 		))
 		dt.filterButtons = filterMenu.Build()
@@ -205,22 +465,38 @@ func (dtb *DataTableBuilder) Build() (*DataTable, error) {
 }
 
 func (d *DataTable) calcStartPage() int64 {
-	if d.pagesCount < 5 { // 5 is pages buttons count
+	window := int64(d.layout.withDefaults().PageWindow)
+	if d.pagesCount <= window {
 		return 1
 	}
-	if (d.currentFilter["pageNum"].(int64)) < 3 { // 3 is center page button
+	startPage := d.currentFilter["pageNum"].(int64) - window/2 // keep the current page centered
+	if startPage < 1 {
 		return 1
 	}
-	if (d.currentFilter["pageNum"].(int64)) >= d.pagesCount-2 {
-		return d.pagesCount - 4
+	if startPage > d.pagesCount-window+1 {
+		return d.pagesCount - window + 1
 	}
-	return (d.currentFilter["pageNum"].(int64)) - 2
+	return startPage
 }
 
 type DataResult struct {
 	Text        string
 	ReplyMarkup [][]button.Button
 	PagesCount  int64
+	// PrevCursor and NextCursor are the cursors of the neighbouring pages in cursor pagination mode.
+	PrevCursor string
+	NextCursor string
+	// HasMore tells whether NextCursor leads to more rows, nil means it does whenever NextCursor is set.
+	HasMore *bool
+	// Rows lists the records on the page. It is only needed for row selection.
+	Rows []Row
+	// Header and Records hold the structured records of the page. They are only needed for exports.
+	Header  []string
+	Records [][]string
+	// Markdown marks Text as already escaped MarkdownV2, e.g. a rendered Table.
+	Markdown bool
+	// Err shows the error view with Retry and Back instead of the page.
+	Err error
 }
 
 /*
@@ -234,6 +510,18 @@ func NewDataResult(text string, replyMarkup [][]button.Button, pagesCount int64)
 	}
 }
 
+/*
+NewDataResultWithRows creates a DataResult like NewDataResult and attaches the rows shown on the page.
+*/
+func NewDataResultWithRows(text string, rows []Row, replyMarkup [][]button.Button, pagesCount int64) DataResult {
+	return DataResult{
+		Text:        text,
+		ReplyMarkup: replyMarkup,
+		PagesCount:  pagesCount,
+		Rows:        rows,
+	}
+}
+
 /*
 NewErrorDataResult creates a DataResult representing an error, with the error message as text.
 */
@@ -245,6 +533,7 @@ func NewErrorDataResult(err error) DataResult {
 		// },
 		ReplyMarkup: nil,
 		PagesCount:  0,
+		Err:         err,
 	}
 }
 
@@ -282,6 +571,9 @@ func New(
 		filterKeys:          filterKeys,
 		currentFilter:       make(map[string]interface{}),
 		b:                   b,
+		labels:              DefaultLabels(),
+		layout:              DefaultLayout(),
+		codec:               callbackdata.NewEncoder(nil),
 	}
 
 	p.currentFilter["pageSize"] = int64(itemPerPage)
@@ -293,7 +585,7 @@ func New(
 		fmt.Println("[datatable] adding filter:", filterKey)
 		filterMenu.Row().Add(button.Button{
 			Text:         filterKey,
-			CallbackData: p.prefix + cbPfxSelectFilterKey + filterKey,
+			CallbackData: cbPfxSelectFilterKey + filterKey,
 			OnClick:      p.nagivateCallback,
 		})
 	}
@@ -301,7 +593,7 @@ func New(
 	if len(filterKeys) > 0 {
 		filterMenu.Row().Add(button.New(
 			CANCEL,
-			p.prefix+cbCmdCancelFilterMenu,
+			cbCmdCancelFilterMenu,
 			p.nagivateCallback,
 		))
 	}
@@ -322,10 +614,14 @@ func defaultOnError(err error) {
 	log.Printf("[datatable] [ERROR] %s", err)
 }
 
-func (p *DataTable) callbackAnswer(ctx context.Context, b *bot.Bot, callbackQuery *models.CallbackQuery) {
-	fmt.Println("callback Answer:")
+func (p *DataTable) callbackAnswer(ctx context.Context, b *bot.Bot, callbackQuery *models.CallbackQuery, text string) {
+	fmt.Println("callback Answer:", text)
+	if router.Answer(ctx, text) {
+		return
+	}
 	ok, err := b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
 		CallbackQueryID: callbackQuery.ID,
+		Text:            text,
 	})
 	if err != nil {
 		p.onError(err)
@@ -345,9 +641,17 @@ func (d *DataTable) nagivateCallback(ctx context.Context, b *bot.Bot, mes models
 	switch command {
 
 	case cbCmdNext:
-		d.handleNextPage(ctx, b, mes)
+		if d.cursorPagination {
+			d.handleNextCursorPage(ctx, b, mes)
+		} else {
+			d.handleNextPage(ctx, b, mes)
+		}
 	case cbCmdBack:
-		d.handlePreviousPage(ctx, b, mes)
+		if d.cursorPagination {
+			d.handlePreviousCursorPage(ctx, b, mes)
+		} else {
+			d.handlePreviousPage(ctx, b, mes)
+		}
 	case cbCmdFilter:
 		d.handleShowFilterMenu(ctx, b, mes)
 	case cbCmdNop:
@@ -356,27 +660,59 @@ func (d *DataTable) nagivateCallback(ctx context.Context, b *bot.Bot, mes models
 		d.handleClose(ctx, b, mes)
 	case cbCmdCancelFilterMenu:
 		d.handleFilterCancel(ctx, b, mes)
+	case cbCmdSelectPage:
+		d.handleSelectPage(ctx, b, mes)
+	case cbCmdClearSelection:
+		d.handleClearSelection(ctx, b, mes)
+	case cbCmdDetailBack:
+		d.handleDetailBack(ctx, b, mes)
+	case cbCmdClearSearch:
+		d.handleClearSearch(ctx, b, mes)
+	case cbCmdExport:
+		d.handleShowExportMenu(ctx, b, mes)
+	case cbCmdExportCancel:
+		d.refresh(ctx, b)
+	case cbCmdRefresh:
+		d.handleRefresh(ctx, b, mes)
+	case cbCmdRetry:
+		d.handleRetry(ctx, b, mes)
+	case cbCmdErrorBack:
+		d.handleErrorBack(ctx, b, mes)
 	default:
 		fmt.Println("[datatable.nagivateCallback] data:", command)
 		if strings.HasPrefix(command, cbPfxSelectFilterKey) {
 			filterKey := strings.TrimPrefix(command, cbPfxSelectFilterKey)
 			d.handleStartFilterQuestionnaire(ctx, b, mes, filterKey)
-		} else if strings.HasPrefix(command, cbPfxSetPage) {
+		} else if strings.HasPrefix(command, cbPfxSetPage) && !d.cursorPagination {
 			pageStr := strings.TrimPrefix(command, cbPfxSetPage)
 			d.handleSetPage(ctx, b, mes, pageStr)
 		} else if strings.HasPrefix(command, cbPfxRemoveFilter) {
 			filterKey := strings.TrimPrefix(command, cbPfxRemoveFilter)
 			d.handleRemoveFilter(ctx, b, mes, filterKey)
+		} else if strings.HasPrefix(command, cbPfxSelectRow) && d.selection != nil {
+			rowID := strings.TrimPrefix(command, cbPfxSelectRow)
+			d.handleSelectRow(ctx, b, mes, rowID)
+		} else if strings.HasPrefix(command, cbPfxBulkAction) && d.selection != nil {
+			indexStr := strings.TrimPrefix(command, cbPfxBulkAction)
+			d.handleBulkAction(ctx, b, mes, indexStr)
+		} else if strings.HasPrefix(command, cbPfxDetail) && d.detailHandler != nil {
+			rowID := strings.TrimPrefix(command, cbPfxDetail)
+			d.handleShowDetail(ctx, b, mes, rowID)
+		} else if strings.HasPrefix(command, cbPfxExportFormat) && len(d.exportFormats) > 0 {
+			format := strings.TrimPrefix(command, cbPfxExportFormat)
+			d.handleExport(ctx, b, mes, format)
 		}
 	}
 
 }
 
-// handleNextPage processes navigation to the next page
+// handleNextPage processes navigation to the next page, a stale click on the last page is ignored
 func (d *DataTable) handleNextPage(ctx context.Context, b *bot.Bot, mes models.MaybeInaccessibleMessage) {
-	fmt.Println("[datatable.handleNextPage] next page")
-	d.currentFilter["pageNum"] = d.currentFilter["pageNum"].(int64) + 1
-	d.Show(ctx, b, d.chatID, d.currentFilter)
+	fmt.Println("[datatable.handleNextPage] next page, current page:", d.currentFilter["pageNum"].(int64))
+	if d.currentFilter["pageNum"].(int64) < d.pagesCount {
+		d.currentFilter["pageNum"] = d.currentFilter["pageNum"].(int64) + 1
+		d.refresh(ctx, b)
+	}
 }
 
 // handlePreviousPage processes navigation to the previous page
@@ -385,42 +721,31 @@ func (d *DataTable) handlePreviousPage(ctx context.Context, b *bot.Bot, mes mode
 	if d.currentFilter["pageNum"].(int64) > 1 {
 		d.currentFilter["pageNum"] = d.currentFilter["pageNum"].(int64) - 1
 		fmt.Println("[datatable.handlePreviousPage] back page", d.currentFilter["pageNum"].(int64))
-		d.Show(ctx, b, d.chatID, d.currentFilter)
+		d.refresh(ctx, b)
 	}
 }
 
-// handleShowFilterMenu displays the filter selection menu
+// handleShowFilterMenu displays the filter selection menu in place of the table
 func (d *DataTable) handleShowFilterMenu(ctx context.Context, b *bot.Bot, mes models.MaybeInaccessibleMessage) {
 	fmt.Println("[datatable.handleShowFilterMenu] filter")
+	d.blur()
 
-	filterNode := inline.New(d.b, inline.WithPrefix(d.prefix+cbPfxSelectFilterKey))
+	filterNode := d.newKeyboard()
 
 	for _, filterButtonRow := range d.filterButtons {
 		filterNode.Row()
 		for _, btn := range filterButtonRow {
-			filterKey := strings.TrimPrefix(btn.CallbackData, d.prefix+cbPfxSelectFilterKey)
+			filterKey := strings.TrimPrefix(btn.CallbackData, cbPfxSelectFilterKey)
 
 			if d.currentFilter[filterKey] != nil {
 				btn.Text = fmt.Sprintf("%s: %v", btn.Text, d.currentFilter[filterKey])
 			}
 
-			filterNode.Button(
-				btn.Text,
-				[]byte(btn.CallbackData),
-				btn.OnClick,
-			)
+			filterNode.Button(btn.Text, btn.CallbackData)
 		}
 	}
 
-	_, err := b.SendMessage(ctx, &bot.SendMessageParams{
-		ChatID:      d.chatID,
-		Text:        FILTER_BY,
-		ParseMode:   models.ParseModeMarkdown,
-		ReplyMarkup: filterNode,
-	})
-	if err != nil {
-		d.onError(err)
-	}
+	d.editMessage(ctx, b, bot.EscapeMarkdown(d.labels.FilterBy), filterNode)
 }
 
 // handleNop handles no-operation callbacks
@@ -432,15 +757,62 @@ func (d *DataTable) handleNop(ctx context.Context, b *bot.Bot, mes models.MaybeI
 // handleClose handles the close button callback
 func (d *DataTable) handleClose(ctx context.Context, b *bot.Bot, mes models.MaybeInaccessibleMessage) {
 	fmt.Println("[datatable.handleClose] close")
+	if err := d.Close(ctx); err != nil {
+		d.onError(err)
+	}
 	if d.onCancelHandler != nil {
 		fmt.Println("[datatable.handleClose] calling onCancelHandler")
 		d.onCancelHandler()
 	}
 }
 
+/*
+Close tears down the table: the message is deleted, or collapsed with WithCollapseOnClose,
+the callback handler is unregistered, and quick search and a pending filter questionnaire are stopped.
+The table can be shown again with Show.
+*/
+func (d *DataTable) Close(ctx context.Context) error {
+	fmt.Println("[datatable.Close] close", d.prefix)
+
+	d.blur()
+	if d.filterQuestionaire != nil {
+		if chatID, ok := d.chatID.(int64); ok && d.questionaireManager.Get(chatID) == d.filterQuestionaire {
+			d.questionaireManager.Remove(chatID)
+		}
+		d.filterQuestionaire = nil
+	}
+
+	d.unregister()
+	d.InvalidateCache()
+	d.detail = nil
+	d.err = nil
+
+	if d.msgID == nil {
+		return nil
+	}
+	msgID := d.msgID.(int)
+	d.msgID = nil
+
+	if d.collapseText != "" {
+		_, err := d.b.EditMessageText(ctx, &bot.EditMessageTextParams{
+			ChatID:    d.chatID,
+			MessageID: msgID,
+			Text:      helper.EscapeTelegramReserved(d.collapseText),
+			ParseMode: models.ParseModeMarkdown,
+		})
+		return err
+	}
+
+	_, err := d.b.DeleteMessage(ctx, &bot.DeleteMessageParams{
+		ChatID:    d.chatID,
+		MessageID: msgID,
+	})
+	return err
+}
+
 // handleFilterCancel handles cancelling the filter menu and returning to the table
 func (d *DataTable) handleFilterCancel(ctx context.Context, b *bot.Bot, mes models.MaybeInaccessibleMessage) {
-	d.Show(ctx, b, d.chatID, d.currentFilter)
+	d.refresh(ctx, b)
 }
 
 // handleStartFilterQuestionnaire starts a questionnaire for a specific filter key
@@ -451,179 +823,295 @@ func (d *DataTable) handleStartFilterQuestionnaire(ctx context.Context, b *bot.B
 	mapKeysChoice[filterKey] = nil
 
 	fun := func(ctx context.Context, b *bot.Bot, chatID any, result map[string]interface{}) error {
+		d.filterQuestionaire = nil
 		// reset current page on filter change
-		d.currentFilter["pageNum"] = int64(1)
+		d.resetPage()
 		d.updateFilter(filterKey, result[filterKey])
 		fmt.Println("[datatable]filter conversation:", d.currentFilter, d.msgID)
+		// resend the table so it stays below the questionnaire messages
+		d.deleteMessage(ctx, b)
 		_, err := d.Show(ctx, b, chatID, d.currentFilter)
 		return err
 	}
 
-	questionaire.NewBuilder(d.chatID, d.questionaireManager).
-		AddQuestion(filterKey, "Enter value for "+filterKey, nil, nil).
+	d.filterQuestionaire = questionaire.NewBuilder(d.chatID, d.questionaireManager).
+		AddQuestion(filterKey, fmt.Sprintf(d.labels.FilterPrompt, filterKey), nil, nil).
 		SetOnDoneHandler(fun).
 		SetAllowEditAnswers(false).
-		Show(ctx, b, d.chatID.(int64))
+		SetRouter(d.router)
+	d.filterQuestionaire.Show(ctx, b, d.chatID.(int64))
 }
 
 // handleSetPage handles navigation to a specific page
 func (d *DataTable) handleSetPage(ctx context.Context, b *bot.Bot, mes models.MaybeInaccessibleMessage, pageStr string) {
 	fmt.Println("[datatable.handleSetPage] set page")
 
-	pageInt, err := strconv.Atoi(pageStr)
+	pageNum, err := d.parsePage(pageStr)
 	if err != nil {
 		d.onError(err)
 		return
 	}
-	d.currentFilter["pageNum"] = int64(pageInt)
+	d.currentFilter["pageNum"] = pageNum
 
 	fmt.Println("[datatable.handleSetPage] set page", d.currentFilter["pageNum"].(int64))
-	d.Show(ctx, b, d.chatID, d.currentFilter)
+	d.refresh(ctx, b)
+}
+
+// parsePage parses the page number of a set page button, it must be a page of the last loaded result
+func (d *DataTable) parsePage(pageStr string) (int64, error) {
+	pageNum, err := strconv.ParseInt(pageStr, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid page %q: %w", pageStr, err)
+	}
+	if pageNum < 1 || pageNum > d.pagesCount {
+		return 0, fmt.Errorf("page %d is out of range 1-%d", pageNum, d.pagesCount)
+	}
+	return pageNum, nil
 }
 
 // handleRemoveFilter handles removing a specific filter
 func (d *DataTable) handleRemoveFilter(ctx context.Context, b *bot.Bot, mes models.MaybeInaccessibleMessage, filterKey string) {
 	fmt.Println("[datatable.handleRemoveFilter] remove filter")
 	d.currentFilter[filterKey] = nil
-	d.Show(ctx, b, d.chatID, d.currentFilter)
+	d.resetPage()
+	d.refresh(ctx, b)
 }
 
 func (d *DataTable) rebuildControls(chatID any) *bot.SendMessageParams {
 	fmt.Println("[datatable] rebuild controls")
 
-	currentPage := int64(d.currentFilter["pageNum"].(int64))
-	navigateNode := inline.New(d.b, inline.WithPrefix(d.prefix))
+	if d.err != nil {
+		return d.rebuildErrorControls(chatID)
+	}
 
-	if d.replyMarkup != nil {
-		fmt.Println("[datatable] replyMarkup", d.replyMarkup)
-		for _, row := range d.replyMarkup {
-			navigateNode.Row()
-			for _, btn := range row {
-				navigateNode.Button(btn.Text, []byte(d.prefix+btn.CallbackData), func(ctx context.Context, bot *bot.Bot, mes models.MaybeInaccessibleMessage, data []byte) {
-					trimmed := strings.TrimPrefix(string(data), d.prefix)
-					fmt.Println("[datatable] callback data:", string(data), "trimmed:", trimmed)
-					btn.OnClick(ctx, bot, mes, []byte(trimmed))
-				})
+	navigateNode := d.newKeyboard()
+
+	for _, row := range d.layout.withDefaults().Rows {
+		switch row {
+		case RowMarkup:
+			if d.replyMarkup != nil {
+				fmt.Println("[datatable] replyMarkup", d.replyMarkup)
+				d.addButtons(navigateNode, d.replyMarkup)
+			}
+		case RowItems:
+			d.addItemControls(navigateNode)
+		case RowPagination:
+			d.addPaginationControls(navigateNode)
+		case RowActions:
+			d.addActionControls(navigateNode)
+		case RowFilterChips:
+			d.addFilterChips(navigateNode)
+		case RowSearch:
+			// show quick search query and allow to remove it
+			if query, ok := d.currentFilter[SearchQueryKey]; ok && query != nil {
+				navigateNode.Row().Button(
+					fmt.Sprintf(d.labels.SearchChip, query),
+					cbCmdClearSearch,
+				)
 			}
+		case RowClose:
+			navigateNode.Row().Button(
+				d.CtrlClose.Text,
+				d.CtrlClose.CallbackData,
+			)
 		}
 	}
 
-	navigateNode.Row()
+	params := &bot.SendMessageParams{
+		ChatID:      chatID,
+		Text:        d.text,
+		ParseMode:   models.ParseModeMarkdown,
+		ReplyMarkup: navigateNode,
+	}
 
-	if d.pagesCount > 1 {
-		startPage := d.calcStartPage()
+	return params
+}
 
-		// Show page 1 button if it's not in current navigation range
-		if startPage > 1 {
-			text := fmt.Sprintf(FIRSTPAGE, 1)
-			callbackCommand := fmt.Sprintf("%s%s1", d.prefix, cbPfxSetPage)
-			navigateNode.Button(
-				text,
-				[]byte(callbackCommand),
-				d.nagivateCallback,
+// addItemControls adds the row detail buttons, or the selection toggles and bulk actions
+func (d *DataTable) addItemControls(navigateNode *controls) {
+	// show rows as detail buttons when rows are not selectable
+	if d.detailHandler != nil && d.selection == nil {
+		for _, row := range d.rows {
+			navigateNode.Row().Button(
+				row.Text,
+				cbPfxDetail+row.ID,
 			)
 		}
+	}
 
-		if currentPage > 1 {
-			navigateNode.Button(d.CtrlBack.Text, []byte(d.prefix+d.CtrlBack.CallbackData), d.nagivateCallback)
-		}
-
-		// Show pagination buttons
-		for i := startPage; i < startPage+5 && i <= d.pagesCount; i++ {
-			text := fmt.Sprintf("%d", i)
-			callbackCommand := fmt.Sprintf("%s%s%d", d.prefix, cbPfxSetPage, i)
+	if d.selection == nil {
+		return
+	}
 
-			if i == currentPage {
-				text = "( " + text + " )"
+	// show selection toggles and bulk actions
+	if len(d.rows) > 0 {
+		for _, row := range d.rows {
+			text := d.labels.Unselected + " " + row.Text
+			if d.selection.isSelected(row.ID) {
+				text = d.labels.Selected + " " + row.Text
 			}
-
-			navigateNode.Button(
+			navigateNode.Row().Button(
 				text,
-				[]byte(callbackCommand),
-				d.nagivateCallback,
+				cbPfxSelectRow+row.ID,
 			)
 		}
 
-		if currentPage < d.pagesCount {
-			navigateNode.Button(d.CtrlNext.Text, []byte(d.prefix+d.CtrlNext.CallbackData), d.nagivateCallback)
+		pageText := d.labels.SelectPage
+		if d.pageSelected() {
+			pageText = d.labels.UnselectPage
+		}
+		navigateNode.Row().Button(pageText, cbCmdSelectPage)
+	}
+
+	if d.selection.len() > 0 {
+		if len(d.rows) == 0 {
+			navigateNode.Row()
+		}
+		navigateNode.Button(
+			fmt.Sprintf(d.labels.ClearSelection, d.selection.len()),
+			cbCmdClearSelection,
+		)
 
-			// Show last page button if it's not in current navigation range
-			lastVisible := startPage + 5 - 1
-			if lastVisible < d.pagesCount {
-				text := fmt.Sprintf(LASTPAGE, d.pagesCount)
-				callbackCommand := fmt.Sprintf("%s%s%d", d.prefix, cbPfxSetPage, d.pagesCount)
+		if len(d.bulkActions) > 0 {
+			navigateNode.Row()
+			for i, action := range d.bulkActions {
 				navigateNode.Button(
-					text,
-					[]byte(callbackCommand),
-					d.nagivateCallback,
+					fmt.Sprintf(d.labels.BulkAction, action.text, d.selection.len()),
+					fmt.Sprintf("%s%d", cbPfxBulkAction, i),
 				)
 			}
 		}
 	}
+}
 
-	// show filter button if there are filter keys
-	if len(d.filterKeys) > 0 {
-		navigateNode.Row().Button(
-			d.CtrlFilter.Text,
-			[]byte(d.prefix+d.CtrlFilter.CallbackData),
-			d.nagivateCallback,
+// addPaginationControls adds the page buttons, or Back and Next in cursor pagination mode
+func (d *DataTable) addPaginationControls(navigateNode *controls) {
+	navigateNode.Row()
+
+	if d.cursorPagination {
+		if d.cursor.hasPrev() {
+			navigateNode.Button(d.CtrlBack.Text, d.CtrlBack.CallbackData)
+		}
+		if d.cursor.hasNext() {
+			navigateNode.Button(d.CtrlNext.Text, d.CtrlNext.CallbackData)
+		}
+		return
+	}
+
+	if d.pagesCount <= 1 {
+		return
+	}
+
+	currentPage := int64(d.currentFilter["pageNum"].(int64))
+	window := int64(d.layout.withDefaults().PageWindow)
+	startPage := d.calcStartPage()
+
+	// Show page 1 button if it's not in current navigation range
+	if startPage > 1 {
+		text := fmt.Sprintf(d.labels.FirstPage, 1)
+		callbackCommand := cbPfxSetPage + "1"
+		navigateNode.Button(
+			text,
+			callbackCommand,
 		)
 	}
 
-	// show current filters and allow to remove them
-	if len(d.currentFilter) > 0 {
+	if currentPage > 1 {
+		navigateNode.Button(d.CtrlBack.Text, d.CtrlBack.CallbackData)
+	}
 
-		for key, value := range d.currentFilter {
-			for _, filter := range d.filterKeys {
-				if filter == key && d.currentFilter[key] != nil {
-					navigateNode.Row().Button(
-						fmt.Sprintf("🗑 %s: %v", key, value),
-						[]byte(d.prefix+cbPfxRemoveFilter+key),
-						d.nagivateCallback,
-					)
-				}
-			}
+	// Show pagination buttons
+	for i := startPage; i < startPage+window && i <= d.pagesCount; i++ {
+		text := fmt.Sprintf("%d", i)
+		callbackCommand := fmt.Sprintf("%s%d", cbPfxSetPage, i)
 
+		if i == currentPage {
+			text = "( " + text + " )"
 		}
+
+		navigateNode.Button(
+			text,
+			callbackCommand,
+		)
 	}
 
-	// show close button
-	navigateNode.Row().Button(
-		d.CtrlClose.Text,
-		[]byte(d.prefix+d.CtrlClose.CallbackData),
-		d.nagivateCallback,
-	)
+	if currentPage < d.pagesCount {
+		navigateNode.Button(d.CtrlNext.Text, d.CtrlNext.CallbackData)
 
-	params := &bot.SendMessageParams{
-		ChatID:      chatID,
-		Text:        helper.EscapeTelegramReserved(d.text),
-		ParseMode:   models.ParseModeMarkdown,
-		ReplyMarkup: navigateNode,
+		// Show last page button if it's not in current navigation range
+		lastVisible := startPage + window - 1
+		if lastVisible < d.pagesCount {
+			text := fmt.Sprintf(d.labels.LastPage, d.pagesCount)
+			callbackCommand := fmt.Sprintf("%s%d", cbPfxSetPage, d.pagesCount)
+			navigateNode.Button(
+				text,
+				callbackCommand,
+			)
+		}
 	}
+}
 
-	return params
+// addActionControls adds the filter button if there are filter keys, export button if exports are enabled
+// and refresh button if pages are cached
+func (d *DataTable) addActionControls(navigateNode *controls) {
+	navigateNode.Row()
+	if len(d.filterKeys) > 0 {
+		navigateNode.Button(
+			d.CtrlFilter.Text,
+			d.CtrlFilter.CallbackData,
+		)
+	}
+	if len(d.exportFormats) > 0 {
+		navigateNode.Button(d.labels.Export, cbCmdExport)
+	}
+	if d.cache != nil {
+		navigateNode.Button(d.labels.Refresh, cbCmdRefresh)
+	}
+}
+
+// addFilterChips shows current filters in the declared order and allows to remove them
+func (d *DataTable) addFilterChips(navigateNode *controls) {
+	for _, key := range d.filterKeys {
+		if value := d.currentFilter[key]; value != nil {
+			navigateNode.Row().Button(
+				fmt.Sprintf(d.labels.FilterChip, key, value),
+				cbPfxRemoveFilter+key,
+			)
+		}
+	}
 }
 
 func (d *DataTable) invokeDataHandler(ctx context.Context, b *bot.Bot, pageSize, pageNum int, filter map[string]interface{}) {
 
-	dataResult := d.dataHandler(ctx, b, pageSize, pageNum, filter)
+	dataResult := d.loadPage(ctx, b, pageSize, pageNum, filter)
 	fmt.Println("[datatable InvokeDataHandler] filter:", filter, "result:", dataResult)
-	d.text = helper.EscapeTelegramReserved(dataResult.Text)
+
+	// keep the last good page for the error view
+	d.err = dataResult.Err
+	if d.err != nil {
+		d.onError(d.err)
+		return
+	}
+
+	d.text = dataResult.Text
+	if !dataResult.Markdown {
+		d.text = helper.EscapeTelegramReserved(dataResult.Text)
+	}
 	d.replyMarkup = dataResult.ReplyMarkup
+	d.rows = dataResult.Rows
 
 	if d.replyMarkup == nil && d.text == "" {
-		d.text = NODATA
+		d.text = bot.EscapeMarkdown(d.labels.NoData)
 	}
 
 	d.pagesCount = dataResult.PagesCount
+	d.cursor.update(dataResult)
+	d.saveGoodPage()
+	d.prefetchNextPage(ctx, b, filter)
 }
 
-/*
-Show displays the DataTable using the provided filter input. The filter input must include pageSize and pageNum.
-*/
-func (d *DataTable) Show(ctx context.Context, b *bot.Bot, chatID any, filterInput map[string]interface{}) (*models.Message, error) {
-	fmt.Println("[datatable] show page , filter:", filterInput)
-	d.saveFilter(filterInput)
+// loadCurrentPage invokes the data handler for the current filters and page
+func (d *DataTable) loadCurrentPage(ctx context.Context, b *bot.Bot) {
 	d.invokeDataHandler(
 		ctx,
 		b,
@@ -631,11 +1119,74 @@ func (d *DataTable) Show(ctx context.Context, b *bot.Bot, chatID any, filterInpu
 		int(d.currentFilter["pageNum"].(int64)),
 		d.currentFilter,
 	)
+}
+
+/*
+Show displays the DataTable using the provided filter input. The filter input must include pageSize and pageNum.
+*/
+func (d *DataTable) Show(ctx context.Context, b *bot.Bot, chatID any, filterInput map[string]interface{}) (*models.Message, error) {
+	fmt.Println("[datatable] show page , filter:", filterInput)
+	d.saveFilter(filterInput)
+	d.loadCurrentPage(ctx, b)
+	return d.send(ctx, b, chatID)
+}
+
+// send sends the loaded page as a new table message
+func (d *DataTable) send(ctx context.Context, b *bot.Bot, chatID any) (*models.Message, error) {
 	params := d.rebuildControls(chatID)
 	m, err := b.SendMessage(ctx, params)
+	if err != nil {
+		return nil, err
+	}
 	d.msgID = m.ID
 	d.chatID = m.Chat.ID
-	return m, err
+	d.detail = nil
+	d.focus()
+	return m, nil
+}
+
+// refresh invokes the data handler and redraws the table in the current message
+func (d *DataTable) refresh(ctx context.Context, b *bot.Bot) {
+	if d.msgID == nil {
+		if _, err := d.Show(ctx, b, d.chatID, d.currentFilter); err != nil {
+			d.onError(err)
+		}
+		return
+	}
+
+	d.loadCurrentPage(ctx, b)
+	params := d.rebuildControls(d.chatID)
+	d.editMessage(ctx, b, params.Text, params.ReplyMarkup)
+	d.focus()
+}
+
+// editMessage replaces the text and keyboard of the table message
+func (d *DataTable) editMessage(ctx context.Context, b *bot.Bot, text string, replyMarkup models.ReplyMarkup) {
+	_, err := b.EditMessageText(ctx, &bot.EditMessageTextParams{
+		ChatID:      d.chatID,
+		MessageID:   d.msgID.(int),
+		Text:        text,
+		ParseMode:   models.ParseModeMarkdown,
+		ReplyMarkup: replyMarkup,
+	})
+	if err != nil && !strings.Contains(err.Error(), "message is not modified") {
+		d.onError(err)
+	}
+}
+
+// deleteMessage removes the table message from the chat
+func (d *DataTable) deleteMessage(ctx context.Context, b *bot.Bot) {
+	if d.msgID == nil {
+		return
+	}
+	_, err := b.DeleteMessage(ctx, &bot.DeleteMessageParams{
+		ChatID:    d.chatID,
+		MessageID: d.msgID.(int),
+	})
+	if err != nil {
+		d.onError(err)
+	}
+	d.msgID = nil
 }
 
 func (d *DataTable) saveFilter(filterInput map[string]interface{}) {
@@ -684,6 +1235,14 @@ func (d *DataTable) saveFilter(filterInput map[string]interface{}) {
 	}
 
 }
+
+// resetPage goes back to the first page, e.g. when the filters change
+func (d *DataTable) resetPage() {
+	d.currentFilter["pageNum"] = int64(1)
+	delete(d.currentFilter, CursorKey)
+	d.cursor = cursorState{}
+}
+
 func (d *DataTable) updateFilter(key string, value interface{}) {
 	d.currentFilter[key] = value
 }
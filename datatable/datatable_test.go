@@ -1,12 +1,26 @@
 package datatable
 
 import (
+	"archive/zip"
+	"bytes"
 	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
 	"testing"
+	"time"
+	"unicode/utf16"
 
 	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
 	"github.com/jkevinp/tgui/button"
+	"github.com/jkevinp/tgui/callbackdata"
+	"github.com/jkevinp/tgui/internal/testbot"
 	"github.com/jkevinp/tgui/questionaire"
+	"github.com/jkevinp/tgui/router"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -166,3 +180,902 @@ func TestDataResultHelpers(t *testing.T) {
 	assert.Nil(t, errorResult.ReplyMarkup)
 	assert.Equal(t, int64(0), errorResult.PagesCount)
 }
+
+func TestBuilderSelection(t *testing.T) {
+	mockBot := &bot.Bot{}
+	mockDataHandler := func(ctx context.Context, b *bot.Bot, pageSize, pageNum int, filter map[string]interface{}) DataResult {
+		return NewDataResult("test", nil, 1)
+	}
+
+	// Selection is disabled by default
+	dt, err := NewBuilder(mockBot).WithDataHandler(mockDataHandler).Build()
+	assert.NoError(t, err)
+	assert.Nil(t, dt.selection)
+	assert.Nil(t, dt.SelectedIDs())
+
+	// Bulk actions enable selection, nil handlers are ignored
+	dt, err = NewBuilder(mockBot).
+		WithDataHandler(mockDataHandler).
+		WithBulkAction("Delete", func(ctx context.Context, b *bot.Bot, mes models.MaybeInaccessibleMessage, ids []string) error {
+			return nil
+		}).
+		WithBulkAction("Export", nil).
+		Build()
+	assert.NoError(t, err)
+	assert.NotNil(t, dt.selection)
+	assert.Len(t, dt.bulkActions, 1)
+}
+
+func TestSelectionAcrossPages(t *testing.T) {
+	dt := &DataTable{selection: newSelection()}
+
+	// Page 1
+	dt.rows = []Row{NewRow("1", "One"), NewRow("2", "Two")}
+	dt.selection.toggle("2")
+	assert.False(t, dt.pageSelected())
+	dt.togglePage()
+	assert.True(t, dt.pageSelected())
+	assert.Equal(t, []string{"2", "1"}, dt.SelectedIDs())
+
+	// Page 2 keeps the selection from page 1
+	dt.rows = []Row{NewRow("3", "Three")}
+	assert.False(t, dt.pageSelected())
+	dt.selection.toggle("3")
+	assert.Equal(t, []string{"2", "1", "3"}, dt.SelectedIDs())
+
+	// Toggling a fully selected page unselects only that page
+	dt.togglePage()
+	assert.Equal(t, []string{"2", "1"}, dt.SelectedIDs())
+
+	// Returned IDs are a copy
+	ids := dt.SelectedIDs()
+	ids[0] = "changed"
+	assert.Equal(t, []string{"2", "1"}, dt.SelectedIDs())
+
+	dt.ClearSelection()
+	assert.Empty(t, dt.SelectedIDs())
+}
+
+func TestDetailView(t *testing.T) {
+	btn := DetailButton("View", "42")
+	assert.Equal(t, "View", btn.Text)
+	assert.Equal(t, cbPfxDetail+"42", btn.CallbackData)
+	assert.Nil(t, btn.OnClick, "detail buttons are handled by the table")
+
+	mockBot := &bot.Bot{}
+	dt, err := NewBuilder(mockBot).
+		WithDataHandler(func(ctx context.Context, b *bot.Bot, pageSize, pageNum int, filter map[string]interface{}) DataResult {
+			return NewDataResult("test", nil, 1)
+		}).
+		Build()
+	assert.NoError(t, err)
+	assert.Error(t, dt.ShowDetail(context.Background(), mockBot, "42"), "ShowDetail requires a detail handler")
+
+	dt.detailHandler = func(ctx context.Context, b *bot.Bot, rowID string) DetailResult {
+		return NewDetailResult("Row "+rowID, nil)
+	}
+	assert.Error(t, dt.ShowDetail(context.Background(), mockBot, "42"), "ShowDetail requires a shown table")
+}
+
+func TestQuickSearch(t *testing.T) {
+	mockBot := &bot.Bot{}
+	mockDataHandler := func(ctx context.Context, b *bot.Bot, pageSize, pageNum int, filter map[string]interface{}) DataResult {
+		return NewDataResult("test", nil, 1)
+	}
+
+	// Quick search needs a manager to receive text messages
+	dt, err := NewBuilder(mockBot).WithDataHandler(mockDataHandler).WithQuickSearch(nil).Build()
+	assert.Error(t, err)
+	assert.Nil(t, dt)
+	assert.Contains(t, err.Error(), "QuickSearch requires a questionaire manager")
+
+	manager := questionaire.NewManager()
+	dt, err = NewBuilder(mockBot).WithDataHandler(mockDataHandler).WithQuickSearch(manager).Build()
+	assert.NoError(t, err)
+	assert.Equal(t, manager, dt.questionaireManager)
+
+	// The table holds the focus only while the list is shown
+	dt.chatID = int64(100)
+	dt.focus()
+	assert.True(t, manager.HasFocus(100, dt.Prefix()))
+	dt.blur()
+	assert.False(t, manager.HasFocus(100, dt.Prefix()))
+
+	// Blurring doesn't remove the focus of another widget
+	manager.SetFocus(100, "other", nil)
+	dt.blur()
+	assert.True(t, manager.HasFocus(100, "other"))
+}
+
+func TestRecordWriters(t *testing.T) {
+	header := []string{"id", "name"}
+	records := [][]string{{"1", "Laptop, Pro"}, {"2", "Mug <XL>"}}
+
+	write := func(format ExportFormat, header []string) string {
+		var buf bytes.Buffer
+		w, err := newRecordWriter(format, &buf)
+		assert.NoError(t, err)
+		if header != nil {
+			assert.NoError(t, w.WriteHeader(header))
+		}
+		for _, record := range records {
+			assert.NoError(t, w.Write(record))
+		}
+		assert.NoError(t, w.Close())
+		return buf.String()
+	}
+
+	assert.Equal(t, "id,name\n1,\"Laptop, Pro\"\n2,Mug <XL>\n", write(ExportCSV, header))
+	assert.Equal(t, `[{"id":"1","name":"Laptop, Pro"},{"id":"2","name":"Mug <XL>"}]`, write(ExportJSON, header))
+	assert.Equal(t, `[["1","Laptop, Pro"],["2","Mug <XL>"]]`, write(ExportJSON, nil))
+
+	// XLSX is a zip archive with the rows streamed into the sheet
+	data := write(ExportXLSX, header)
+	archive, err := zip.NewReader(strings.NewReader(data), int64(len(data)))
+	assert.NoError(t, err)
+	var sheet string
+	for _, f := range archive.File {
+		if f.Name == "xl/worksheets/sheet1.xml" {
+			rc, err := f.Open()
+			assert.NoError(t, err)
+			content, _ := io.ReadAll(rc)
+			sheet = string(content)
+		}
+	}
+	assert.Len(t, archive.File, 5)
+	assert.Contains(t, sheet, `<t xml:space="preserve">Mug &lt;XL&gt;</t>`)
+	assert.Equal(t, 3, strings.Count(sheet, "<row>"))
+
+	_, err = newRecordWriter("pdf", &bytes.Buffer{})
+	assert.Error(t, err)
+}
+
+func TestBuilderExport(t *testing.T) {
+	mockBot := &bot.Bot{}
+	mockDataHandler := func(ctx context.Context, b *bot.Bot, pageSize, pageNum int, filter map[string]interface{}) DataResult {
+		return NewDataResult("test", nil, 1)
+	}
+
+	dt, err := NewBuilder(mockBot).WithDataHandler(mockDataHandler).WithExport().Build()
+	assert.NoError(t, err)
+	assert.Equal(t, []ExportFormat{ExportCSV, ExportJSON, ExportXLSX}, dt.exportFormats)
+
+	dt, err = NewBuilder(mockBot).WithDataHandler(mockDataHandler).WithExport(ExportCSV).Build()
+	assert.NoError(t, err)
+	assert.Equal(t, []ExportFormat{ExportCSV}, dt.exportFormats)
+}
+
+func TestExportPages(t *testing.T) {
+	calls := 0
+	dt := &DataTable{
+		currentFilter: map[string]interface{}{"pageSize": int64(2), "pageNum": int64(2), "status": "active"},
+		dataHandler: func(ctx context.Context, b *bot.Bot, pageSize, pageNum int, filter map[string]interface{}) DataResult {
+			calls++
+			assert.Equal(t, "active", filter["status"], "export keeps the current filters")
+			records := [][]string{{fmt.Sprint(pageNum*2 - 1)}, {fmt.Sprint(pageNum * 2)}}
+			return NewDataResult("", nil, 3).WithRecords([]string{"id"}, records)
+		},
+	}
+
+	var buf bytes.Buffer
+	w, _ := newRecordWriter(ExportCSV, &buf)
+	assert.NoError(t, dt.exportPages(context.Background(), nil, copyFilter(dt.currentFilter), w))
+	assert.NoError(t, w.Close())
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, "id\n1\n2\n3\n4\n5\n6\n", buf.String())
+	assert.Equal(t, int64(2), dt.currentFilter["pageNum"], "export doesn't change the current page")
+}
+
+func TestExportPagesError(t *testing.T) {
+	for _, failAt := range []int{1, 2} {
+		dt := &DataTable{
+			currentFilter: map[string]interface{}{"pageSize": int64(2), "pageNum": int64(1)},
+			dataHandler: func(ctx context.Context, b *bot.Bot, pageSize, pageNum int, filter map[string]interface{}) DataResult {
+				if pageNum == failAt {
+					return NewErrorDataResult(assert.AnError)
+				}
+				return NewDataResult("", nil, 3).WithRecords([]string{"id"}, [][]string{{fmt.Sprint(pageNum)}})
+			},
+		}
+
+		var buf bytes.Buffer
+		w, _ := newRecordWriter(ExportCSV, &buf)
+		err := dt.exportPages(context.Background(), nil, copyFilter(dt.currentFilter), w)
+		assert.ErrorIs(t, err, assert.AnError, "the error of page %d is returned, not a cut-off export", failAt)
+	}
+}
+
+func TestExportFormatNotEnabled(t *testing.T) {
+	b, server := testbot.New(t)
+
+	var errs []error
+	dt, err := NewBuilder(b).
+		WithDataHandler(func(ctx context.Context, b *bot.Bot, pageSize, pageNum int, filter map[string]interface{}) DataResult {
+			return NewDataResult("test", nil, 1).WithRecords([]string{"id"}, [][]string{{"1"}})
+		}).
+		WithExport(ExportCSV).
+		WithOnErrorHandler(func(err error) { errs = append(errs, err) }).
+		Build()
+	assert.NoError(t, err)
+	dt.chatID = int64(42)
+	dt.msgID = 1
+
+	assert.ErrorIs(t, dt.Export(context.Background(), b, ExportXLSX), ErrExportFormat)
+	assert.ErrorIs(t, dt.Export(context.Background(), b, "../../tmp/x"), ErrExportFormat)
+	assert.ErrorIs(t, dt.validateCommand(cbPfxExportFormat+"json"), ErrExportFormat)
+	assert.NoError(t, dt.validateCommand(cbPfxExportFormat+"csv"))
+	assert.NoError(t, dt.validateCommand(cbCmdExportCancel))
+
+	dt.callback(context.Background(), b, &models.Update{CallbackQuery: &models.CallbackQuery{ID: "1", Data: dt.Prefix() + cbPfxExportFormat + "xlsx"}})
+	assert.Len(t, errs, 1)
+	assert.ErrorIs(t, errs[0], ErrExportFormat)
+	assert.Contains(t, server.Last(), callbackdata.InvalidButtonText)
+	assert.NotContains(t, server.Methods(), "sendDocument")
+}
+
+func TestTableRender(t *testing.T) {
+	table := NewTable(
+		Column{Header: "ID", Align: AlignRight},
+		Column{Header: "Name", Width: 8},
+		Column{Header: "Price", Align: AlignRight},
+	).
+		AddRow("1", "Laptop Pro 15", "1299.99").
+		AddRow("12", "Mug `x`", "5")
+
+	expected := "```\n" +
+		"ID │ Name     │   Price\n" +
+		"───┼──────────┼────────\n" +
+		" 1 │ Laptop … │ 1299.99\n" +
+		"12 │ Mug \\`x\\`  │       5\n" +
+		"```"
+	assert.Equal(t, expected, table.Render())
+
+	// Columns that don't fit are dropped
+	table.MaxWidth = 15
+	assert.NotContains(t, table.Render(), "Price")
+
+	// or continued on the next line
+	table.WrapColumns = true
+	rendered := table.Render()
+	assert.Contains(t, rendered, "Price")
+	for _, line := range strings.Split(rendered, "\n") {
+		assert.LessOrEqual(t, len([]rune(strings.ReplaceAll(line, "\\", ""))), 15)
+	}
+	assert.Equal(t, []string{"```", "ID │ Name", "───┼─────────", "  Price", "───────"}, strings.Split(rendered, "\n")[:5],
+		"each column group has its rule below its header")
+
+	// Wrapped values take several lines
+	wrapped := NewTable(Column{Header: "Text", Width: 4, Wrap: true}).AddRow("abcdefghij").Render()
+	assert.Equal(t, "```\nText\n────\nabcd\nefgh\nij\n```", wrapped)
+}
+
+func TestTableRenderLength(t *testing.T) {
+	table := NewTable(Column{Header: "Value"})
+	for i := 0; i < 1000; i++ {
+		table.AddRow(fmt.Sprintf("row number %d", i))
+	}
+
+	result := NewTableDataResult("Title", table, nil, 1)
+	assert.True(t, result.Markdown)
+	assert.LessOrEqual(t, len([]rune(result.Text)), MaxMessageLength)
+	assert.True(t, strings.HasPrefix(result.Text, "Title\n```\n"))
+	assert.True(t, strings.HasSuffix(result.Text, "more rows\n```"))
+
+	// Telegram counts the limit in UTF-16 code units, an emoji takes two
+	wide := NewTable(Column{Header: "Value"})
+	for i := 0; i < 1000; i++ {
+		wide.AddRow(fmt.Sprintf("😀😀😀 %d", i))
+	}
+	result = NewTableDataResult("Title 😀", wide, nil, 1)
+	assert.LessOrEqual(t, len(utf16.Encode([]rune(result.Text))), MaxMessageLength)
+	assert.Greater(t, len(utf16.Encode([]rune(result.Text))), MaxMessageLength-100, "the budget is filled")
+}
+
+func TestCursorPagination(t *testing.T) {
+	dt := &DataTable{
+		cursorPagination: true,
+		currentFilter:    map[string]interface{}{"pageSize": int64(2), "pageNum": int64(1)},
+	}
+
+	// First page, the handler only returns a next cursor
+	dt.cursor.update(NewCursorDataResult("", nil, "", "c2"))
+	assert.False(t, dt.cursor.hasPrev())
+	assert.True(t, dt.cursor.hasNext())
+
+	assert.True(t, dt.nextCursorPage())
+	assert.Equal(t, "c2", dt.currentFilter[CursorKey])
+	assert.Equal(t, int64(2), dt.currentFilter["pageNum"])
+
+	// Last page, HasMore hides Next even though a cursor is returned
+	dt.cursor.update(NewCursorDataResult("", nil, "", "c3").WithHasMore(false))
+	assert.False(t, dt.cursor.hasNext())
+	assert.False(t, dt.nextCursorPage())
+
+	// Prev falls back to the remembered cursor of the first page
+	assert.True(t, dt.cursor.hasPrev())
+	assert.True(t, dt.prevCursorPage())
+	_, ok := dt.currentFilter[CursorKey]
+	assert.False(t, ok, "first page has no cursor")
+	assert.Equal(t, int64(1), dt.currentFilter["pageNum"])
+
+	// Filter changes start over
+	dt.cursor.update(NewCursorDataResult("", nil, "", "c2"))
+	dt.nextCursorPage()
+	dt.resetPage()
+	_, ok = dt.currentFilter[CursorKey]
+	assert.False(t, ok)
+	assert.Empty(t, dt.cursor.history)
+}
+
+func TestExportCursorPages(t *testing.T) {
+	next := map[string]string{"": "b", "b": "c", "c": ""}
+	dt := &DataTable{
+		cursorPagination: true,
+		currentFilter:    map[string]interface{}{"pageSize": int64(1), "pageNum": int64(2), CursorKey: "b"},
+		dataHandler: func(ctx context.Context, b *bot.Bot, pageSize, pageNum int, filter map[string]interface{}) DataResult {
+			cursor, _ := filter[CursorKey].(string)
+			return NewCursorDataResult("", nil, "", next[cursor]).WithRecords(nil, [][]string{{"row " + cursor}})
+		},
+	}
+
+	var buf bytes.Buffer
+	w, _ := newRecordWriter(ExportCSV, &buf)
+	assert.NoError(t, dt.exportPages(context.Background(), nil, copyFilter(dt.currentFilter), w))
+	assert.NoError(t, w.Close())
+	assert.Equal(t, "row \nrow b\nrow c\n", buf.String())
+	assert.Equal(t, "b", dt.currentFilter[CursorKey], "export doesn't change the current page")
+}
+
+func TestPageCache(t *testing.T) {
+	now := time.Now()
+	cache := newPageCache(time.Minute, 2)
+	cache.now = func() time.Time { return now }
+
+	key1 := pageCacheKey(map[string]interface{}{"pageNum": int64(1), "status": "active", "name": nil})
+	assert.Equal(t, key1, pageCacheKey(map[string]interface{}{"status": "active", "pageNum": int64(1)}), "key ignores map order and nil filters")
+	key2 := pageCacheKey(map[string]interface{}{"pageNum": int64(2), "status": "active"})
+	key3 := pageCacheKey(map[string]interface{}{"pageNum": int64(3), "status": "active"})
+	assert.NotEqual(t, key1, key2)
+
+	cache.put(key1, NewDataResult("page 1", nil, 3), 0)
+	cache.put(key2, NewDataResult("page 2", nil, 3), 0)
+	result, ok := cache.get(key1)
+	assert.True(t, ok)
+	assert.Equal(t, "page 1", result.Text)
+
+	// page 2 is the least recently used
+	cache.put(key3, NewDataResult("page 3", nil, 3), 0)
+	assert.Equal(t, 2, cache.len())
+	assert.False(t, cache.contains(key2))
+	assert.True(t, cache.contains(key1))
+
+	now = now.Add(2 * time.Minute)
+	_, ok = cache.get(key1)
+	assert.False(t, ok, "expired entries are not returned")
+
+	cache.clear()
+	assert.Equal(t, 0, cache.len())
+
+	// a fetch started before the clear doesn't cache its result
+	cache.put(key1, NewDataResult("stale", nil, 3), 0)
+	assert.False(t, cache.contains(key1))
+	cache.put(key1, NewDataResult("page 1", nil, 3), cache.gen())
+	assert.True(t, cache.contains(key1))
+}
+
+func TestFetchPageCache(t *testing.T) {
+	calls := 0
+	dt := &DataTable{
+		cache:         newPageCache(0, 0),
+		currentFilter: map[string]interface{}{"pageSize": int64(2), "pageNum": int64(1)},
+		dataHandler: func(ctx context.Context, b *bot.Bot, pageSize, pageNum int, filter map[string]interface{}) DataResult {
+			calls++
+			return NewDataResult(fmt.Sprintf("page %d", pageNum), nil, 3)
+		},
+	}
+
+	ctx := context.Background()
+	assert.Equal(t, "page 1", dt.fetchPage(ctx, nil, 2, 1, dt.currentFilter).Text)
+	dt.currentFilter["pageNum"] = int64(2)
+	dt.fetchPage(ctx, nil, 2, 2, dt.currentFilter)
+	dt.currentFilter["pageNum"] = int64(1)
+	assert.Equal(t, "page 1", dt.fetchPage(ctx, nil, 2, 1, dt.currentFilter).Text)
+	assert.Equal(t, 2, calls, "going back to a seen page uses the cache")
+
+	dt.InvalidateCache()
+	dt.fetchPage(ctx, nil, 2, 1, dt.currentFilter)
+	assert.Equal(t, 3, calls)
+}
+
+func TestFetchPageInvalidated(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	dt := &DataTable{
+		cache: newPageCache(0, 0),
+		dataHandler: func(ctx context.Context, b *bot.Bot, pageSize, pageNum int, filter map[string]interface{}) DataResult {
+			close(started)
+			<-release
+			return NewDataResult("old data", nil, 3)
+		},
+	}
+
+	filter := map[string]interface{}{"pageSize": int64(2), "pageNum": int64(2)}
+	done := make(chan struct{})
+	go func() {
+		dt.fetchPage(context.Background(), nil, 2, 2, filter)
+		close(done)
+	}()
+
+	// a refresh invalidates the cache while the prefetch runs
+	<-started
+	dt.InvalidateCache()
+	close(release)
+	<-done
+	assert.False(t, dt.cache.contains(pageCacheKey(filter)), "the prefetched old data is dropped")
+}
+
+func TestBuilderPageCache(t *testing.T) {
+	builder := NewBuilder(&bot.Bot{}).WithDataHandler(func(ctx context.Context, b *bot.Bot, pageSize, pageNum int, filter map[string]interface{}) DataResult {
+		return NewDataResult("test", nil, 1)
+	})
+
+	dt, err := builder.WithPrefetch().Build()
+	assert.Error(t, err)
+	assert.Nil(t, dt)
+
+	dt, err = NewBuilder(&bot.Bot{}).WithDataHandler(func(ctx context.Context, b *bot.Bot, pageSize, pageNum int, filter map[string]interface{}) DataResult {
+		return NewDataResult("test", nil, 1)
+	}).WithPageCache(0, 0).WithPrefetch().Build()
+	assert.NoError(t, err)
+	assert.NotNil(t, dt.cache, "a zero ttl and size is a cache without limits")
+
+	dt, err = builder.WithPageCache(time.Minute, 50).Build()
+	assert.NoError(t, err)
+	assert.NotNil(t, dt.cache)
+	assert.True(t, dt.prefetch)
+}
+
+func TestErrorState(t *testing.T) {
+	fail := false
+	dt := &DataTable{
+		onError:       func(err error) {},
+		currentFilter: map[string]interface{}{"pageSize": int64(2), "pageNum": int64(1)},
+		dataHandler: func(ctx context.Context, b *bot.Bot, pageSize, pageNum int, filter map[string]interface{}) DataResult {
+			if fail {
+				return NewErrorDataResult(assert.AnError)
+			}
+			return NewDataResult(fmt.Sprintf("page %d", pageNum), nil, 3)
+		},
+	}
+
+	ctx := context.Background()
+	dt.invokeDataHandler(ctx, nil, 2, 1, dt.currentFilter)
+	assert.NoError(t, dt.err)
+	assert.Equal(t, "page 1", dt.text)
+
+	// a failing page keeps the last good page and filters
+	fail = true
+	dt.currentFilter["pageNum"] = int64(2)
+	dt.invokeDataHandler(ctx, nil, 2, 2, dt.currentFilter)
+	assert.ErrorIs(t, dt.err, assert.AnError)
+	assert.Equal(t, "page 1", dt.text)
+	assert.Equal(t, int64(1), dt.lastGood.filter["pageNum"])
+	assert.Equal(t, int64(3), dt.pagesCount)
+}
+
+func TestLoadPageTimeout(t *testing.T) {
+	dt := &DataTable{
+		timeout: 10 * time.Millisecond,
+		dataHandler: func(ctx context.Context, b *bot.Bot, pageSize, pageNum int, filter map[string]interface{}) DataResult {
+			<-ctx.Done()
+			return NewDataResult("too late", nil, 1)
+		},
+	}
+
+	result := dt.loadPage(context.Background(), nil, 2, 1, map[string]interface{}{})
+	assert.ErrorIs(t, result.Err, ErrDataTimeout)
+}
+
+func TestLoadPageTimeoutFilterCopy(t *testing.T) {
+	changed := make(chan struct{})
+	seen := make(chan interface{}, 1)
+	dt := &DataTable{
+		timeout: 10 * time.Millisecond,
+		dataHandler: func(ctx context.Context, b *bot.Bot, pageSize, pageNum int, filter map[string]interface{}) DataResult {
+			<-ctx.Done()
+			<-changed
+			seen <- filter["name"]
+			return NewDataResult("too late", nil, 1)
+		},
+	}
+
+	filter := map[string]interface{}{"pageSize": int64(2), "pageNum": int64(1), "name": "Ann"}
+	result := dt.loadPage(context.Background(), nil, 2, 1, filter)
+	assert.ErrorIs(t, result.Err, ErrDataTimeout)
+
+	// the next click changes the filter while the timed out handler still runs
+	filter["name"] = "Bob"
+	delete(filter, "pageNum")
+	close(changed)
+	assert.Equal(t, "Ann", <-seen, "the handler has a copy of the filter")
+}
+
+func TestBuilderLabels(t *testing.T) {
+	handler := func(ctx context.Context, b *bot.Bot, pageSize, pageNum int, filter map[string]interface{}) DataResult {
+		return NewDataResult("test", nil, 1)
+	}
+
+	dt, err := NewBuilder(&bot.Bot{}).WithDataHandler(handler).Build()
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultLabels(), dt.labels)
+	assert.Equal(t, DefaultLayout(), dt.layout)
+
+	dt, err = NewBuilder(&bot.Bot{}).
+		WithDataHandler(handler).
+		WithLabels("", Labels{Close: "✖️"}).
+		WithLabels("es", Labels{Filter: "🔎 Filtrar", Next: "Siguiente", FilterChip: "🗑 %s = %v", Selected: "☑️"}).
+		WithLabels("de", Labels{Filter: "🔎 Filtern"}).
+		WithLocale("es").
+		Build()
+	assert.NoError(t, err)
+	assert.Equal(t, "🔎 Filtrar", dt.CtrlFilter.Text)
+	assert.Equal(t, "Siguiente", dt.CtrlNext.Text)
+	assert.Equal(t, "✖️", dt.CtrlClose.Text, "labels for the empty locale apply to all locales")
+	assert.Equal(t, BACK, dt.CtrlBack.Text, "missing labels fall back to the defaults")
+	assert.Equal(t, "🗑 %s = %v", dt.labels.FilterChip)
+	assert.Equal(t, "☑️", dt.labels.Selected)
+	assert.Equal(t, ERROR_VIEW, dt.labels.ErrorView)
+
+	dt.labels.ErrorView = "Oops! (%s)"
+	dt.err = errors.New("db.orders failed")
+	assert.Equal(t, `Oops\! \(db\.orders failed\)`, dt.rebuildErrorControls(int64(42)).Text, "the label is escaped too")
+
+	dt.filterKeys = []string{"status", "customer", "region", "city"}
+	dt.currentFilter = map[string]interface{}{"status": "open", "customer": "ann", "region": nil, "city": "Oslo"}
+	for i := 0; i < 20; i++ {
+		chips := newControls("dt", callbackdata.NewEncoder(nil), func(error) {})
+		dt.addFilterChips(chips)
+		assert.Len(t, chips.markup, 3)
+		assert.Equal(t, "🗑 status = open", chips.markup[0][0].Text)
+		assert.Equal(t, "🗑 customer = ann", chips.markup[1][0].Text, "chips keep the declared filter order")
+		assert.Equal(t, "🗑 city = Oslo", chips.markup[2][0].Text)
+	}
+
+	dt, err = NewBuilder(&bot.Bot{}).WithDataHandler(handler).WithLabels("es", Labels{Filter: "🔎 Filtrar"}).WithLocale("fr").Build()
+	assert.NoError(t, err)
+	assert.Equal(t, FILTER, dt.CtrlFilter.Text)
+}
+
+func TestCalcStartPage(t *testing.T) {
+	dt := &DataTable{currentFilter: map[string]interface{}{}, pagesCount: 10}
+
+	cases := []struct {
+		window  int
+		page    int64
+		startAt int64
+	}{
+		{5, 1, 1}, {5, 3, 1}, {5, 4, 2}, {5, 8, 6}, {5, 10, 6},
+		{7, 1, 1}, {7, 5, 2}, {7, 10, 4},
+		{3, 5, 4}, {3, 10, 8},
+		{0, 4, 2}, // default window
+	}
+	for _, c := range cases {
+		dt.layout = Layout{PageWindow: c.window}
+		dt.currentFilter["pageNum"] = c.page
+		assert.Equal(t, c.startAt, dt.calcStartPage(), "window %d, page %d", c.window, c.page)
+	}
+
+	dt.pagesCount = 4
+	dt.currentFilter["pageNum"] = int64(4)
+	dt.layout = Layout{}
+	assert.Equal(t, int64(1), dt.calcStartPage())
+}
+
+func TestLayoutRows(t *testing.T) {
+	b, err := bot.New("123:token", bot.WithSkipGetMe())
+	assert.NoError(t, err)
+
+	dt, err := NewBuilder(b).
+		WithDataHandler(func(ctx context.Context, b *bot.Bot, pageSize, pageNum int, filter map[string]interface{}) DataResult {
+			return NewDataResult("test", nil, 20)
+		}).
+		WithFiltering(nil, []string{"status"}).
+		WithLayout(Layout{PageWindow: 3, Rows: []ControlRow{RowClose, RowPagination}}).
+		Build()
+	assert.NoError(t, err)
+
+	dt.currentFilter["pageNum"] = int64(10)
+	dt.pagesCount = 20
+	params := dt.rebuildControls(int64(1))
+
+	markup, err := json.Marshal(params.ReplyMarkup)
+	assert.NoError(t, err)
+	var kb models.InlineKeyboardMarkup
+	assert.NoError(t, json.Unmarshal(markup, &kb))
+
+	texts := make([][]string, len(kb.InlineKeyboard))
+	for i, row := range kb.InlineKeyboard {
+		for _, btn := range row {
+			texts[i] = append(texts[i], btn.Text)
+		}
+	}
+	assert.Equal(t, [][]string{
+		{CLOSE},
+		{"1 ⏮️ ", BACK, "9", "( 10 )", "11", NEXT, "20 ⏭️"},
+	}, texts, "hidden rows are left out, e.g. the filter button")
+}
+
+func TestShareToken(t *testing.T) {
+	dt := &DataTable{
+		name:          "orders",
+		filterKeys:    []string{"status", "customer"},
+		quickSearch:   true,
+		currentFilter: map[string]interface{}{"pageSize": int64(10), "pageNum": int64(3), "status": "pending", "secret": "x"},
+	}
+
+	token, err := dt.ShareToken()
+	assert.NoError(t, err)
+	assert.LessOrEqual(t, len(token), MaxStartPayloadLength)
+	assert.Regexp(t, `^orders_[A-Za-z0-9_-]+$`, token)
+
+	link, err := dt.DeepLink("@shop_bot")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://t.me/shop_bot?start="+token, link)
+
+	// restore into a table showing something else
+	other := &DataTable{
+		name:          "orders",
+		filterKeys:    []string{"status", "customer"},
+		quickSearch:   true,
+		currentFilter: map[string]interface{}{"pageSize": int64(10), "pageNum": int64(7), "customer": "acme", SearchQueryKey: "mug"},
+	}
+	name, state, err := parseShareToken(token)
+	assert.NoError(t, err)
+	assert.Equal(t, "orders", name)
+	assert.NoError(t, other.restoreViewState(state))
+	assert.Equal(t, map[string]interface{}{"pageSize": int64(10), "pageNum": int64(3), "status": "pending"}, other.currentFilter)
+
+	// the first page without filters is just the name
+	dt.currentFilter = map[string]interface{}{"pageSize": int64(10), "pageNum": int64(1)}
+	token, err = dt.ShareToken()
+	assert.NoError(t, err)
+	assert.Equal(t, "orders", token)
+
+	dt.currentFilter["status"] = strings.Repeat("pending", 10)
+	_, err = dt.ShareToken()
+	assert.ErrorIs(t, err, ErrShareTokenTooLong)
+
+	_, _, err = parseShareToken("orders_!!")
+	assert.ErrorIs(t, err, ErrInvalidShareToken)
+
+	// filters the table doesn't declare are rejected
+	_, state, err = parseShareToken("orders_" + base64.RawURLEncoding.EncodeToString([]byte("f.owner=root")))
+	assert.NoError(t, err)
+	assert.ErrorIs(t, other.restoreViewState(state), ErrInvalidShareToken)
+	_, state, _ = parseShareToken("orders_" + base64.RawURLEncoding.EncodeToString([]byte("pageSize=1000")))
+	assert.ErrorIs(t, other.restoreViewState(state), ErrInvalidShareToken)
+
+	dt.name = ""
+	_, err = dt.ShareToken()
+	assert.Error(t, err)
+}
+
+func TestShowFromStart(t *testing.T) {
+	b, server := testbot.New(t)
+	var pages []int
+	dt, err := NewBuilder(b).
+		WithName("orders").
+		WithSigningKey([]byte("secret")).
+		WithFiltering(questionaire.NewManager(), []string{"status"}).
+		WithDataHandler(func(ctx context.Context, b *bot.Bot, pageSize, pageNum int, filter map[string]interface{}) DataResult {
+			pages = append(pages, pageNum)
+			return NewDataResult(fmt.Sprintf("page %d", pageNum), nil, 3)
+		}).
+		Build()
+	assert.NoError(t, err)
+
+	dt.currentFilter["pageNum"] = int64(9)
+	dt.currentFilter["status"] = "pending"
+	token, err := dt.ShareToken()
+	assert.NoError(t, err)
+	assert.Regexp(t, `^orders_[A-Za-z0-9_-]+$`, token)
+
+	start := func(payload string) (bool, error) {
+		update := &models.Update{Message: &models.Message{Text: "/start " + payload, Chat: models.Chat{ID: 42}}}
+		return dt.ShowFromStart(context.Background(), b, update)
+	}
+
+	// a page past the last one shows the last page
+	shown, err := start(token)
+	assert.True(t, shown)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{9, 3}, pages)
+	assert.Equal(t, int64(3), dt.currentFilter["pageNum"])
+	assert.Equal(t, "pending", dt.currentFilter["status"])
+
+	_, err = start(token[:len(token)-1] + "A")
+	assert.ErrorIs(t, err, ErrInvalidShareToken, "tampered signature")
+	unsigned := &DataTable{name: "orders", filterKeys: []string{"status"}, currentFilter: map[string]interface{}{"pageNum": int64(2)}}
+	forged, err := unsigned.ShareToken()
+	assert.NoError(t, err)
+	_, err = start(forged)
+	assert.ErrorIs(t, err, ErrInvalidShareToken, "unsigned token")
+
+	dt.currentFilter = map[string]interface{}{"pageSize": int64(10), "pageNum": int64(1)}
+	token, err = dt.ShareToken()
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(token, "orders_"), "the signature follows the separator")
+	server.Reset()
+	shown, err = start(token)
+	assert.True(t, shown)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"deleteMessage", "sendMessage"}, server.Methods(), "the old table message is removed")
+}
+
+func TestShowFromStartOtherPayload(t *testing.T) {
+	dt := &DataTable{name: "orders", currentFilter: map[string]interface{}{}}
+
+	for _, text := range []string{"/start", "/start users_cD0z", "/start hello.world", "hello orders", "/help orders"} {
+		update := &models.Update{Message: &models.Message{Text: text}}
+		shown, err := dt.ShowFromStart(context.Background(), nil, update)
+		assert.False(t, shown, text)
+		assert.NoError(t, err, text)
+	}
+
+	shown, err := dt.ShowFromStart(context.Background(), nil, &models.Update{Message: &models.Message{Text: "/start orders_!!"}})
+	assert.False(t, shown)
+	assert.ErrorIs(t, err, ErrInvalidShareToken)
+}
+
+func TestClose(t *testing.T) {
+	b, err := bot.New("123:token", bot.WithSkipGetMe())
+	assert.NoError(t, err)
+	manager := questionaire.NewManager()
+	r := router.New(b)
+
+	dt, err := NewBuilder(b).
+		WithRouter(r).
+		WithDataHandler(func(ctx context.Context, b *bot.Bot, pageSize, pageNum int, filter map[string]interface{}) DataResult {
+			return NewDataResult("test", nil, 3)
+		}).
+		WithQuickSearch(manager).
+		Build()
+	assert.NoError(t, err)
+
+	dt.chatID = int64(42)
+	dt.rebuildControls(dt.chatID)
+	dt.rebuildControls(dt.chatID)
+	dt.focus()
+	assert.Equal(t, 1, r.Len(), "one handler per table")
+	assert.True(t, manager.HasFocus(42, dt.Prefix()))
+
+	assert.NoError(t, dt.Close(context.Background()))
+	assert.Equal(t, 0, r.Len())
+	assert.False(t, manager.HasFocus(42, dt.Prefix()))
+
+	// closing twice is harmless
+	assert.NoError(t, dt.Close(context.Background()))
+}
+
+func TestHandlerCountAcrossRenders(t *testing.T) {
+	b, _ := testbot.New(t)
+
+	var clicked []string
+	onClick := func(ctx context.Context, b *bot.Bot, mes models.MaybeInaccessibleMessage, data []byte) {
+		clicked = append(clicked, string(data))
+	}
+	r := router.New(b)
+	dt, err := NewBuilder(b).
+		WithRouter(r).
+		WithDataHandler(func(ctx context.Context, b *bot.Bot, pageSize, pageNum int, filter map[string]interface{}) DataResult {
+			return NewDataResult("test", nil, 3)
+		}).
+		WithFiltering(questionaire.NewManager(), []string{"status"}).
+		Build()
+	assert.NoError(t, err)
+
+	dt.chatID = int64(42)
+	dt.msgID = 1
+	for i := 0; i < 100; i++ {
+		dt.replyMarkup = [][]button.Button{{button.New("Open", fmt.Sprintf("open:%d", i), onClick)}}
+		dt.rebuildControls(dt.chatID)
+		dt.handleShowFilterMenu(context.Background(), b, models.MaybeInaccessibleMessage{})
+	}
+	assert.Equal(t, 1, r.Len(), "one handler per table")
+
+	// the caller's button receives its own callback data
+	dt.rebuildControls(dt.chatID)
+	assert.Len(t, dt.clickHandlers, 1, "caller buttons are reset with every render")
+	dt.callback(context.Background(), b, &models.Update{CallbackQuery: &models.CallbackQuery{
+		ID:   "1",
+		Data: dt.Prefix() + cbPfxButton + "0",
+	}})
+	assert.Equal(t, []string{"open:99"}, clicked)
+}
+
+func TestBuilderCallbackDataLength(t *testing.T) {
+	b, err := bot.New("123:token", bot.WithSkipGetMe())
+	assert.NoError(t, err)
+	manager := questionaire.NewManager()
+	dataHandler := func(ctx context.Context, b *bot.Bot, pageSize, pageNum int, filter map[string]interface{}) DataResult {
+		return NewDataResult("test", nil, 1)
+	}
+	longKey := strings.Repeat("k", 40)
+
+	_, err = NewBuilder(b).WithDataHandler(dataHandler).WithFiltering(manager, []string{"status", longKey}).Build()
+	assert.ErrorIs(t, err, callbackdata.ErrTooLong)
+	assert.Contains(t, err.Error(), longKey)
+
+	dt, err := NewBuilder(b).
+		WithDataHandler(dataHandler).
+		WithFiltering(manager, []string{longKey}).
+		WithOverflowStore(callbackdata.NewMemoryStore()).
+		Build()
+	assert.NoError(t, err)
+
+	dt.currentFilter[longKey] = "x"
+	params := dt.rebuildControls(int64(42))
+	data, err := json.Marshal(params.ReplyMarkup)
+	assert.NoError(t, err)
+	var markup models.InlineKeyboardMarkup
+	assert.NoError(t, json.Unmarshal(data, &markup))
+
+	// the filter chip carries a store key, which decodes to the remove command
+	var chip string
+	for _, row := range markup.InlineKeyboard {
+		for _, btn := range row {
+			assert.LessOrEqual(t, len(btn.CallbackData), callbackdata.MaxLength)
+			if strings.Contains(btn.Text, "x") {
+				chip = btn.CallbackData
+			}
+		}
+	}
+	command, err := dt.codec.Decode(dt.Prefix(), chip)
+	assert.NoError(t, err)
+	assert.Equal(t, cbPfxRemoveFilter+longKey, command)
+}
+
+func TestCallbackInvalidData(t *testing.T) {
+	b, server := testbot.New(t)
+
+	var errs []error
+	dt, err := NewBuilder(b).
+		WithDataHandler(func(ctx context.Context, b *bot.Bot, pageSize, pageNum int, filter map[string]interface{}) DataResult {
+			return NewDataResult("test", nil, 3)
+		}).
+		WithSigningKey([]byte("secret")).
+		WithOnErrorHandler(func(err error) { errs = append(errs, err) }).
+		Build()
+	assert.NoError(t, err)
+	dt.chatID = int64(42)
+	dt.msgID = 1
+	dt.pagesCount = 3
+
+	click := func(data string) {
+		dt.callback(context.Background(), b, &models.Update{CallbackQuery: &models.CallbackQuery{ID: "1", Data: data}})
+	}
+	signed, err := dt.codec.Encode(dt.Prefix(), cbPfxSetPage+"2")
+	assert.NoError(t, err)
+
+	click(dt.Prefix() + cbPfxSetPage + "2")
+	click(strings.Replace(signed, cbPfxSetPage+"2", cbPfxSetPage+"3", 1))
+	outOfRange, err := dt.codec.Encode(dt.Prefix(), cbPfxSetPage+"9")
+	assert.NoError(t, err)
+	click(outOfRange)
+	assert.Len(t, errs, 3)
+	answers := server.Bodies("answerCallbackQuery")
+	assert.Len(t, answers, 3)
+	for _, answer := range answers {
+		assert.Contains(t, answer, callbackdata.InvalidButtonText)
+	}
+	assert.Equal(t, int64(1), dt.currentFilter["pageNum"])
+
+	click(signed)
+	assert.Equal(t, int64(2), dt.currentFilter["pageNum"])
+
+	next, err := dt.codec.Encode(dt.Prefix(), cbCmdNext)
+	assert.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		click(next)
+	}
+	assert.Equal(t, int64(3), dt.currentFilter["pageNum"], "repeated next clicks stop at the last page")
+}
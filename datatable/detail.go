@@ -0,0 +1,119 @@
+package datatable
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/jkevinp/tgui/button"
+	"github.com/jkevinp/tgui/helper"
+)
+
+const (
+	BACK_TO_LIST = "⬅️ Back to list"
+
+	cbPfxDetail     = "detail_" // Followed by row ID, e.g., "detail_42"
+	cbCmdDetailBack = "detailback"
+)
+
+// DetailResult holds the detail view of a single row.
+// Actions are shown above the "Back to list" button.
+type DetailResult struct {
+	Text    string
+	Actions [][]button.Button
+}
+
+/*
+NewDetailResult creates a DetailResult with the provided text and action buttons.
+*/
+func NewDetailResult(text string, actions [][]button.Button) DetailResult {
+	return DetailResult{
+		Text:    text,
+		Actions: actions,
+	}
+}
+
+// detailHandlerFunc renders the detail view of the row with the given ID.
+type detailHandlerFunc func(ctx context.Context, b *bot.Bot, rowID string) DetailResult
+
+// detailState remembers the list view the detail view was opened from.
+type detailState struct {
+	rowID  string
+	filter map[string]interface{}
+}
+
+/*
+DetailButton creates a button for DataResult.ReplyMarkup that opens the detail view of the row.
+*/
+func DetailButton(text string, rowID string) button.Button {
+	return button.Button{
+		Text:         text,
+		CallbackData: cbPfxDetail + rowID,
+	}
+}
+
+/*
+ShowDetail renders the detail view of the row in the table message.
+The current page and filters are restored when the user goes back to the list.
+*/
+func (d *DataTable) ShowDetail(ctx context.Context, b *bot.Bot, rowID string) error {
+	if d.detailHandler == nil {
+		return errors.New("datatable: DetailHandler is not set")
+	}
+	if d.msgID == nil {
+		return errors.New("datatable: table is not shown")
+	}
+
+	if d.detail == nil {
+		d.detail = &detailState{filter: copyFilter(d.currentFilter)}
+	}
+	d.detail.rowID = rowID
+	d.blur()
+
+	result := d.detailHandler(ctx, b, rowID)
+
+	detailNode := d.newKeyboard()
+	d.addButtons(detailNode, result.Actions)
+	detailNode.Row().Button(
+		d.labels.BackToList,
+		cbCmdDetailBack,
+	)
+
+	d.editMessage(ctx, b, helper.EscapeTelegramReserved(result.Text), detailNode)
+	return nil
+}
+
+/*
+BackToList leaves the detail view and shows the list with the page and filters it was opened from.
+*/
+func (d *DataTable) BackToList(ctx context.Context, b *bot.Bot) {
+	if d.detail != nil {
+		d.currentFilter = d.detail.filter
+		d.detail = nil
+	}
+	d.refresh(ctx, b)
+}
+
+// handleShowDetail opens the detail view of a row
+func (d *DataTable) handleShowDetail(ctx context.Context, b *bot.Bot, mes models.MaybeInaccessibleMessage, rowID string) {
+	fmt.Println("[datatable.handleShowDetail] row:", rowID)
+	if err := d.ShowDetail(ctx, b, rowID); err != nil {
+		d.onError(err)
+	}
+}
+
+// handleDetailBack returns from the detail view to the list
+func (d *DataTable) handleDetailBack(ctx context.Context, b *bot.Bot, mes models.MaybeInaccessibleMessage) {
+	fmt.Println("[datatable.handleDetailBack] back to list")
+	d.BackToList(ctx, b)
+}
+
+func copyFilter(filter map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(filter))
+	for key, value := range filter {
+		result[key] = value
+	}
+	return result
+}
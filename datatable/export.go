@@ -0,0 +1,181 @@
+package datatable
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+const (
+	EXPORT         = "📤 Export"
+	EXPORT_FORMAT  = "Export format"
+	EXPORT_CAPTION = "📤 %d rows"
+
+	cbCmdExport       = "export"       // Opens the export format menu
+	cbPfxExportFormat = "export_"      // Followed by format, e.g., "export_csv"
+	cbCmdExportCancel = "exportcancel" // Closes the export format menu, returns to table
+)
+
+// ErrExportFormat is returned for an export format not enabled with WithExport.
+var ErrExportFormat = errors.New("datatable: export format not enabled")
+
+// exportHandlerFunc writes all records matching the filter to w.
+type exportHandlerFunc func(ctx context.Context, b *bot.Bot, filter map[string]interface{}, w RecordWriter) error
+
+/*
+WithRecords returns a copy of the DataResult with the structured records of the page attached.
+Records are used when exporting the table without a dedicated export handler.
+*/
+func (r DataResult) WithRecords(header []string, records [][]string) DataResult {
+	r.Header = header
+	r.Records = records
+	return r
+}
+
+/*
+Export writes all records matching the current filters to a document and sends it to the chat.
+The records are written to a temporary file as they are produced, so large results are not held in memory.
+Formats not enabled with WithExport return ErrExportFormat.
+*/
+func (d *DataTable) Export(ctx context.Context, b *bot.Bot, format ExportFormat) error {
+	if !d.exportFormatEnabled(format) {
+		return fmt.Errorf("%w: %q", ErrExportFormat, format)
+	}
+
+	file, err := os.CreateTemp("", "datatable-export-*."+string(format))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	rw, err := newRecordWriter(format, file)
+	if err != nil {
+		return err
+	}
+	w := &countingRecordWriter{recordWriteCloser: rw}
+
+	filter := copyFilter(d.currentFilter)
+	if d.exportHandler != nil {
+		err = d.exportHandler(ctx, b, filter, w)
+	} else {
+		err = d.exportPages(ctx, b, filter, w)
+	}
+	if err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	_, err = b.SendDocument(ctx, &bot.SendDocumentParams{
+		ChatID: d.chatID,
+		Document: &models.InputFileUpload{
+			Filename: fmt.Sprintf("export-%s.%s", time.Now().Format("20060102-150405"), format),
+			Data:     file,
+		},
+		Caption: fmt.Sprintf(d.labels.ExportCaption, w.count),
+	})
+	return err
+}
+
+// exportPages re-runs the data handler over all pages and writes the records of each page.
+func (d *DataTable) exportPages(ctx context.Context, b *bot.Bot, filter map[string]interface{}, w RecordWriter) error {
+	pageSize := int(filter["pageSize"].(int64))
+	headerWritten := false
+	if d.cursorPagination {
+		delete(filter, CursorKey)
+	}
+
+	for pageNum := 1; ; pageNum++ {
+		filter["pageNum"] = int64(pageNum)
+		result := d.dataHandler(ctx, b, pageSize, pageNum, filter)
+		if result.Err != nil {
+			return result.Err
+		}
+		if result.Records == nil {
+			if pageNum == 1 {
+				return errors.New("datatable: data handler returned no records to export")
+			}
+			return nil
+		}
+
+		if !headerWritten && result.Header != nil {
+			if err := w.WriteHeader(result.Header); err != nil {
+				return err
+			}
+		}
+		headerWritten = true
+
+		for _, record := range result.Records {
+			if err := w.Write(record); err != nil {
+				return err
+			}
+		}
+
+		if len(result.Records) == 0 {
+			return nil
+		}
+		if d.cursorPagination {
+			cursor := cursorState{}
+			cursor.update(result)
+			if !cursor.hasNext() {
+				return nil
+			}
+			filter[CursorKey] = cursor.next
+		} else if int64(pageNum) >= result.PagesCount {
+			return nil
+		}
+	}
+}
+
+// handleShowExportMenu displays the export format menu in place of the table
+func (d *DataTable) handleShowExportMenu(ctx context.Context, b *bot.Bot, mes models.MaybeInaccessibleMessage) {
+	fmt.Println("[datatable.handleShowExportMenu] export")
+	d.blur()
+
+	exportNode := d.newKeyboard().Row()
+	for _, format := range d.exportFormats {
+		exportNode.Button(
+			strings.ToUpper(string(format)),
+			cbPfxExportFormat+string(format),
+		)
+	}
+	exportNode.Row().Button(d.labels.Cancel, cbCmdExportCancel)
+
+	d.editMessage(ctx, b, bot.EscapeMarkdown(d.labels.ExportFormat), exportNode)
+}
+
+// exportFormatEnabled reports whether the format was enabled with WithExport
+func (d *DataTable) exportFormatEnabled(format ExportFormat) bool {
+	for _, enabled := range d.exportFormats {
+		if enabled == format {
+			return true
+		}
+	}
+	return false
+}
+
+// handleExport exports the table in the selected format and returns to the table
+func (d *DataTable) handleExport(ctx context.Context, b *bot.Bot, mes models.MaybeInaccessibleMessage, format string) {
+	fmt.Println("[datatable.handleExport] format:", format)
+	if err := d.Export(ctx, b, ExportFormat(format)); err != nil {
+		d.onError(err)
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: d.chatID,
+			Text:   err.Error(),
+		})
+	}
+	d.refresh(ctx, b)
+}
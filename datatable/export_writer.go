@@ -0,0 +1,239 @@
+package datatable
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ExportFormat is the document format of an export.
+type ExportFormat string
+
+const (
+	ExportCSV  ExportFormat = "csv"
+	ExportJSON ExportFormat = "json"
+	ExportXLSX ExportFormat = "xlsx"
+)
+
+// RecordWriter receives the exported records one by one, so they don't have to be held in memory.
+// WriteHeader must be called before the first record if the export has column names.
+type RecordWriter interface {
+	WriteHeader(header []string) error
+	Write(record []string) error
+}
+
+// recordWriteCloser is a RecordWriter that finishes the document on Close.
+type recordWriteCloser interface {
+	RecordWriter
+	Close() error
+}
+
+func newRecordWriter(format ExportFormat, w io.Writer) (recordWriteCloser, error) {
+	switch format {
+	case ExportCSV:
+		return &csvRecordWriter{w: csv.NewWriter(w)}, nil
+	case ExportJSON:
+		return &jsonRecordWriter{w: bufio.NewWriter(w)}, nil
+	case ExportXLSX:
+		return newXLSXRecordWriter(w)
+	default:
+		return nil, fmt.Errorf("datatable: unknown export format %q", format)
+	}
+}
+
+// csvRecordWriter writes records as comma separated values.
+type csvRecordWriter struct {
+	w *csv.Writer
+}
+
+func (c *csvRecordWriter) WriteHeader(header []string) error {
+	return c.w.Write(header)
+}
+
+func (c *csvRecordWriter) Write(record []string) error {
+	return c.w.Write(record)
+}
+
+func (c *csvRecordWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// jsonRecordWriter writes records as a JSON array.
+// Records are objects keyed by the header in column order, or arrays when there is no header.
+type jsonRecordWriter struct {
+	w       *bufio.Writer
+	header  []string
+	started bool
+}
+
+func (j *jsonRecordWriter) WriteHeader(header []string) error {
+	j.header = header
+	return nil
+}
+
+func (j *jsonRecordWriter) Write(record []string) error {
+	sep := ","
+	if !j.started {
+		sep = "["
+		j.started = true
+	}
+	if _, err := j.w.WriteString(sep); err != nil {
+		return err
+	}
+
+	if j.header == nil {
+		return j.encode(record)
+	}
+
+	j.w.WriteString("{")
+	for i, value := range record {
+		key := fmt.Sprintf("column%d", i+1)
+		if i < len(j.header) {
+			key = j.header[i]
+		}
+		if i > 0 {
+			j.w.WriteString(",")
+		}
+		j.encode(key)
+		j.w.WriteString(":")
+		j.encode(value)
+	}
+	_, err := j.w.WriteString("}")
+	return err
+}
+
+// encode writes v without HTML escaping, so exported values stay readable.
+func (j *jsonRecordWriter) encode(v any) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	_, err := j.w.Write(bytes.TrimSuffix(buf.Bytes(), []byte("\n")))
+	return err
+}
+
+func (j *jsonRecordWriter) Close() error {
+	if !j.started {
+		j.w.WriteString("[")
+	}
+	if _, err := j.w.WriteString("]"); err != nil {
+		return err
+	}
+	return j.w.Flush()
+}
+
+const (
+	xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+		`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`
+	xlsxRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+		`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`
+	xlsxWorkbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+		`<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="Sheet1" sheetId="1" r:id="rId1"/></sheets>` +
+		`</workbook>`
+	xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+		`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`</Relationships>`
+	xlsxSheetStart = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+		`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+	xlsxSheetEnd = `</sheetData></worksheet>`
+)
+
+// xlsxRecordWriter writes records as a single sheet workbook with inline strings.
+// The sheet is the last part of the archive, so rows are streamed into it as they arrive.
+type xlsxRecordWriter struct {
+	zip   *zip.Writer
+	sheet *bufio.Writer
+}
+
+func newXLSXRecordWriter(w io.Writer) (*xlsxRecordWriter, error) {
+	zw := zip.NewWriter(w)
+
+	parts := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRels},
+		{"xl/workbook.xml", xlsxWorkbook},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+	}
+	for _, part := range parts {
+		pw, err := zw.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(pw, part.content); err != nil {
+			return nil, err
+		}
+	}
+
+	sw, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	sheet := bufio.NewWriter(sw)
+	if _, err := sheet.WriteString(xlsxSheetStart); err != nil {
+		return nil, err
+	}
+
+	return &xlsxRecordWriter{zip: zw, sheet: sheet}, nil
+}
+
+func (x *xlsxRecordWriter) WriteHeader(header []string) error {
+	return x.Write(header)
+}
+
+func (x *xlsxRecordWriter) Write(record []string) error {
+	var row strings.Builder
+	row.WriteString("<row>")
+	for _, value := range record {
+		row.WriteString(`<c t="inlineStr"><is><t xml:space="preserve">`)
+		xml.EscapeText(&row, []byte(value))
+		row.WriteString("</t></is></c>")
+	}
+	row.WriteString("</row>")
+	_, err := x.sheet.WriteString(row.String())
+	return err
+}
+
+func (x *xlsxRecordWriter) Close() error {
+	if _, err := x.sheet.WriteString(xlsxSheetEnd); err != nil {
+		return err
+	}
+	if err := x.sheet.Flush(); err != nil {
+		return err
+	}
+	return x.zip.Close()
+}
+
+// countingRecordWriter counts the records written, not including the header.
+type countingRecordWriter struct {
+	recordWriteCloser
+	count int
+}
+
+func (c *countingRecordWriter) Write(record []string) error {
+	if err := c.recordWriteCloser.Write(record); err != nil {
+		return err
+	}
+	c.count++
+	return nil
+}
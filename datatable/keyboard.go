@@ -0,0 +1,150 @@
+package datatable
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/jkevinp/tgui/button"
+	"github.com/jkevinp/tgui/callbackdata"
+)
+
+const (
+	cbPfxButton = "btn_" // Followed by the index of a caller supplied button in the current render, e.g., "btn_2"
+)
+
+// controls is the inline keyboard of the table message.
+// Buttons carry the table prefix followed by a command, so the one handler registered per table serves every render.
+type controls struct {
+	prefix  string
+	codec   *callbackdata.Encoder
+	onError OnErrorHandler
+	markup  [][]models.InlineKeyboardButton
+}
+
+func newControls(prefix string, codec *callbackdata.Encoder, onError OnErrorHandler) *controls {
+	return &controls{
+		prefix:  prefix,
+		codec:   codec,
+		onError: onError,
+		markup:  [][]models.InlineKeyboardButton{{}},
+	}
+}
+
+// Row starts a new row, unless the current row is still empty.
+func (c *controls) Row() *controls {
+	if len(c.markup[len(c.markup)-1]) > 0 {
+		c.markup = append(c.markup, []models.InlineKeyboardButton{})
+	}
+	return c
+}
+
+// Button adds a button sending the command to the table.
+// A command too long for the callback data is left out and reported to the error handler, unless the table has an overflow store.
+func (c *controls) Button(text string, command string) *controls {
+	data, err := c.codec.Encode(c.prefix, command)
+	if err != nil {
+		c.onError(fmt.Errorf("button %q: %w", text, err))
+		return c
+	}
+	c.markup[len(c.markup)-1] = append(c.markup[len(c.markup)-1], models.InlineKeyboardButton{
+		Text:         text,
+		CallbackData: data,
+	})
+	return c
+}
+
+func (c *controls) MarshalJSON() ([]byte, error) {
+	return json.Marshal(models.InlineKeyboardMarkup{InlineKeyboard: c.markup})
+}
+
+// newKeyboard creates the keyboard for the next render.
+// The table's callback handler is registered with the first render and kept until Close.
+// A routed table gets a new prefix from the router with every registration.
+func (d *DataTable) newKeyboard() *controls {
+	if d.callbackHandlerID == "" && d.router != nil {
+		d.callbackHandlerID, d.prefix = d.router.Handle("dt", d.callback)
+	} else if d.callbackHandlerID == "" {
+		d.callbackHandlerID = d.b.RegisterHandler(bot.HandlerTypeCallbackQueryData, d.prefix, bot.MatchTypePrefix, d.callback)
+	}
+	d.clickHandlers = nil
+	return newControls(d.prefix, d.codec, d.onError)
+}
+
+// unregister removes the table's callback handler from the bot.
+func (d *DataTable) unregister() {
+	if d.callbackHandlerID != "" && d.router != nil {
+		d.router.Remove(d.callbackHandlerID)
+	} else if d.callbackHandlerID != "" {
+		d.b.UnregisterHandler(d.callbackHandlerID)
+	}
+	d.callbackHandlerID = ""
+	d.clickHandlers = nil
+}
+
+// addButtons adds caller supplied buttons to the keyboard.
+// Buttons without OnClick are handled by the table itself, e.g. DetailButton.
+// Buttons with OnClick are numbered per render and receive their CallbackData when clicked.
+func (d *DataTable) addButtons(kb *controls, rows [][]button.Button) {
+	for _, row := range rows {
+		kb.Row()
+		for _, btn := range row {
+			if btn.OnClick == nil {
+				kb.Button(btn.Text, btn.CallbackData)
+				continue
+			}
+			d.clickHandlers = append(d.clickHandlers, btn)
+			kb.Button(btn.Text, cbPfxButton+strconv.Itoa(len(d.clickHandlers)-1))
+		}
+	}
+}
+
+// callback receives every button click of the table.
+// The callback is answered before the command runs, so the button doesn't keep spinning while a slow data handler runs.
+// Invalid, tampered or expired callback data is answered with callbackdata.InvalidButtonText.
+func (d *DataTable) callback(ctx context.Context, b *bot.Bot, update *models.Update) {
+	command, err := d.codec.Decode(d.prefix, update.CallbackQuery.Data)
+	if err == nil {
+		err = d.validateCommand(command)
+	}
+	if err != nil {
+		d.onError(fmt.Errorf("button %q: %w", update.CallbackQuery.Data, err))
+		d.callbackAnswer(ctx, b, update.CallbackQuery, callbackdata.InvalidButtonText)
+		return
+	}
+	d.callbackAnswer(ctx, b, update.CallbackQuery, "")
+
+	if !strings.HasPrefix(command, cbPfxButton) {
+		d.nagivateCallback(ctx, b, update.CallbackQuery.Message, []byte(d.prefix+command))
+		return
+	}
+
+	index, _ := strconv.Atoi(strings.TrimPrefix(command, cbPfxButton))
+	btn := d.clickHandlers[index]
+	fmt.Println("[datatable] button:", btn.Text, "callback data:", btn.CallbackData)
+	btn.OnClick(ctx, b, update.CallbackQuery.Message, []byte(btn.CallbackData))
+}
+
+// validateCommand checks the bounds of the button index or page number and the export format in the command
+func (d *DataTable) validateCommand(command string) error {
+	switch {
+	case strings.HasPrefix(command, cbPfxButton):
+		index, err := strconv.Atoi(strings.TrimPrefix(command, cbPfxButton))
+		if err != nil || index < 0 || index >= len(d.clickHandlers) {
+			return fmt.Errorf("unknown button: %s", command)
+		}
+	case strings.HasPrefix(command, cbPfxSetPage):
+		if _, err := d.parsePage(strings.TrimPrefix(command, cbPfxSetPage)); err != nil {
+			return err
+		}
+	case strings.HasPrefix(command, cbPfxExportFormat):
+		if format := ExportFormat(strings.TrimPrefix(command, cbPfxExportFormat)); !d.exportFormatEnabled(format) {
+			return fmt.Errorf("%w: %q", ErrExportFormat, format)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+package datatable
+
+// Labels holds the texts of the DataTable controls.
+// Empty fields fall back to the default English labels.
+type Labels struct {
+	Filter       string
+	Next         string
+	Back         string
+	Close        string
+	NoData       string
+	FilterBy     string
+	FilterPrompt string // Formatted with the filter key
+	FilterChip   string // Formatted with the filter key and value
+	SearchChip   string // Formatted with the search query
+	Cancel       string
+	LastPage     string // Formatted with the last page number
+	FirstPage    string // Formatted with the first page number
+
+	Export        string
+	ExportFormat  string
+	ExportCaption string // Formatted with the number of exported rows
+	Refresh       string
+	Loading       string
+	Retry         string
+	BackToList    string
+	ErrorView     string // Formatted with the error message
+
+	Selected       string
+	Unselected     string
+	SelectPage     string
+	UnselectPage   string
+	ClearSelection string // Formatted with the number of selected rows
+	BulkAction     string // Formatted with the action text and the number of selected rows
+}
+
+/*
+DefaultLabels returns the default English labels.
+*/
+func DefaultLabels() Labels {
+	return Labels{
+		Filter:       FILTER,
+		Next:         NEXT,
+		Back:         BACK,
+		Close:        CLOSE,
+		NoData:       NODATA,
+		FilterBy:     FILTER_BY,
+		FilterPrompt: FILTER_PROMPT,
+		FilterChip:   FILTER_CHIP,
+		SearchChip:   SEARCH_CHIP,
+		Cancel:       CANCEL,
+		LastPage:     LASTPAGE,
+		FirstPage:    FIRSTPAGE,
+
+		Export:        EXPORT,
+		ExportFormat:  EXPORT_FORMAT,
+		ExportCaption: EXPORT_CAPTION,
+		Refresh:       REFRESH,
+		Loading:       LOADING,
+		Retry:         RETRY,
+		BackToList:    BACK_TO_LIST,
+		ErrorView:     ERROR_VIEW,
+
+		Selected:       SELECTED,
+		Unselected:     UNSELECTED,
+		SelectPage:     SELECT_PAGE,
+		UnselectPage:   UNSELECT_PAGE,
+		ClearSelection: CLEAR_SELECTION,
+		BulkAction:     BULK_ACTION,
+	}
+}
+
+// withDefaults fills the empty labels from defaults.
+func (l Labels) withDefaults(defaults Labels) Labels {
+	fill := func(s *string, def string) {
+		if *s == "" {
+			*s = def
+		}
+	}
+	fill(&l.Filter, defaults.Filter)
+	fill(&l.Next, defaults.Next)
+	fill(&l.Back, defaults.Back)
+	fill(&l.Close, defaults.Close)
+	fill(&l.NoData, defaults.NoData)
+	fill(&l.FilterBy, defaults.FilterBy)
+	fill(&l.FilterPrompt, defaults.FilterPrompt)
+	fill(&l.FilterChip, defaults.FilterChip)
+	fill(&l.SearchChip, defaults.SearchChip)
+	fill(&l.Cancel, defaults.Cancel)
+	fill(&l.LastPage, defaults.LastPage)
+	fill(&l.FirstPage, defaults.FirstPage)
+	fill(&l.Export, defaults.Export)
+	fill(&l.ExportFormat, defaults.ExportFormat)
+	fill(&l.ExportCaption, defaults.ExportCaption)
+	fill(&l.Refresh, defaults.Refresh)
+	fill(&l.Loading, defaults.Loading)
+	fill(&l.Retry, defaults.Retry)
+	fill(&l.BackToList, defaults.BackToList)
+	fill(&l.ErrorView, defaults.ErrorView)
+	fill(&l.Selected, defaults.Selected)
+	fill(&l.Unselected, defaults.Unselected)
+	fill(&l.SelectPage, defaults.SelectPage)
+	fill(&l.UnselectPage, defaults.UnselectPage)
+	fill(&l.ClearSelection, defaults.ClearSelection)
+	fill(&l.BulkAction, defaults.BulkAction)
+	return l
+}
+
+// ControlRow is a group of controls in the table keyboard.
+type ControlRow int
+
+const (
+	// RowMarkup is the keyboard returned by the data handler in DataResult.ReplyMarkup
+	RowMarkup ControlRow = iota
+	// RowItems are the row detail buttons, or the selection toggles with the selection controls
+	RowItems
+	// RowPagination are the page buttons
+	RowPagination
+	// RowActions are the Filter, Export and Refresh buttons
+	RowActions
+	// RowFilterChips are the active filters, tapped to remove them
+	RowFilterChips
+	// RowSearch is the quick search query, tapped to clear it
+	RowSearch
+	// RowClose is the Close button
+	RowClose
+)
+
+const defaultPageWindow = 5
+
+// Layout sets which control rows are shown and in which order.
+type Layout struct {
+	// PageWindow is the number of page number buttons (default: 5)
+	PageWindow int
+	// Rows lists the control rows from top to bottom, rows not listed are hidden (default: all rows in declaration order)
+	Rows []ControlRow
+}
+
+/*
+DefaultLayout returns the default layout: five page buttons and all control rows.
+*/
+func DefaultLayout() Layout {
+	return Layout{
+		PageWindow: defaultPageWindow,
+		Rows:       []ControlRow{RowMarkup, RowItems, RowPagination, RowActions, RowFilterChips, RowSearch, RowClose},
+	}
+}
+
+// withDefaults fills the unset fields from the default layout.
+func (l Layout) withDefaults() Layout {
+	defaults := DefaultLayout()
+	if l.PageWindow <= 0 {
+		l.PageWindow = defaults.PageWindow
+	}
+	if l.Rows == nil {
+		l.Rows = defaults.Rows
+	}
+	return l
+}
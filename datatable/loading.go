@@ -0,0 +1,117 @@
+package datatable
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+const (
+	LOADING    = "⏳ Loading…"
+	ERROR_VIEW = "⚠️ %s"
+	RETRY      = "🔁 Retry"
+
+	cbCmdRetry     = "retry"     // Calls the data handler again after an error
+	cbCmdErrorBack = "errorback" // Returns from the error view to the last good page
+
+	// loadingDelay is how long the data handler may run before the loading state is shown
+	loadingDelay = 500 * time.Millisecond
+)
+
+// ErrDataTimeout is the error shown when the data handler doesn't return within the timeout set with WithTimeout.
+var ErrDataTimeout = errors.New("datatable: loading data timed out")
+
+// pageState is the last page rendered without an error, restored by Back in the error view.
+type pageState struct {
+	filter map[string]interface{}
+	cursor cursorState
+}
+
+// loadPage calls the data handler and shows the loading state in the table message while it runs.
+// It gives up with ErrDataTimeout after the timeout; the handler keeps running in the background.
+func (d *DataTable) loadPage(ctx context.Context, b *bot.Bot, pageSize, pageNum int, filter map[string]interface{}) DataResult {
+	loadCtx := ctx
+	if d.timeout > 0 {
+		var cancel context.CancelFunc
+		loadCtx, cancel = context.WithTimeout(ctx, d.timeout)
+		defer cancel()
+	}
+
+	// the handler may outlive the timeout, while the next click changes the current filter
+	filter = copyFilter(filter)
+	resultCh := make(chan DataResult, 1)
+	go func() {
+		resultCh <- d.fetchPage(loadCtx, b, pageSize, pageNum, filter)
+	}()
+
+	loading := time.NewTimer(loadingDelay)
+	defer loading.Stop()
+
+	for {
+		select {
+		case result := <-resultCh:
+			return result
+		case <-loading.C:
+			if d.msgID != nil {
+				fmt.Println("[datatable.loadPage] loading page:", pageNum)
+				d.editMessage(ctx, b, bot.EscapeMarkdown(d.labels.Loading), nil)
+			}
+		case <-loadCtx.Done():
+			err := loadCtx.Err()
+			if errors.Is(err, context.DeadlineExceeded) {
+				err = ErrDataTimeout
+			}
+			return NewErrorDataResult(err)
+		}
+	}
+}
+
+// saveGoodPage remembers the shown page, so the error view can return to it.
+func (d *DataTable) saveGoodPage() {
+	cursor := d.cursor
+	cursor.history = append([]string(nil), d.cursor.history...)
+	d.lastGood = &pageState{filter: copyFilter(d.currentFilter), cursor: cursor}
+}
+
+// rebuildErrorControls renders the error view with Retry, Back to the last good page and Close.
+func (d *DataTable) rebuildErrorControls(chatID any) *bot.SendMessageParams {
+	errorNode := d.newKeyboard().Row().Button(d.labels.Retry, cbCmdRetry)
+	if d.lastGood != nil {
+		errorNode.Button(d.labels.BackToList, cbCmdErrorBack)
+	}
+	errorNode.Row().Button(d.CtrlClose.Text, d.CtrlClose.CallbackData)
+
+	return &bot.SendMessageParams{
+		ChatID:      chatID,
+		Text:        bot.EscapeMarkdown(fmt.Sprintf(d.labels.ErrorView, d.err.Error())),
+		ParseMode:   models.ParseModeMarkdown,
+		ReplyMarkup: errorNode,
+	}
+}
+
+// handleRetry calls the data handler again for the page that failed
+func (d *DataTable) handleRetry(ctx context.Context, b *bot.Bot, mes models.MaybeInaccessibleMessage) {
+	fmt.Println("[datatable.handleRetry] retry")
+	d.refresh(ctx, b)
+}
+
+// handleErrorBack restores the filters of the last good page and shows it again without calling the data handler
+func (d *DataTable) handleErrorBack(ctx context.Context, b *bot.Bot, mes models.MaybeInaccessibleMessage) {
+	fmt.Println("[datatable.handleErrorBack] back to last good page")
+	if d.lastGood == nil {
+		d.refresh(ctx, b)
+		return
+	}
+
+	d.currentFilter = copyFilter(d.lastGood.filter)
+	d.cursor = d.lastGood.cursor
+	d.err = nil
+
+	params := d.rebuildControls(d.chatID)
+	d.editMessage(ctx, b, params.Text, params.ReplyMarkup)
+	d.focus()
+}
@@ -0,0 +1,332 @@
+package datatable
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/jkevinp/tgui/button"
+	"github.com/jkevinp/tgui/helper"
+)
+
+const (
+	// MaxMessageLength is the maximum length of a Telegram message text, in UTF-16 code units.
+	MaxMessageLength = 4096
+
+	MORE_ROWS = "… %d more rows"
+
+	defaultTableWidth     = 40
+	defaultTableSeparator = " │ "
+	codeBlockFence        = "```"
+)
+
+// Alignment is the horizontal alignment of a column.
+type Alignment int
+
+const (
+	AlignLeft Alignment = iota
+	AlignRight
+	AlignCenter
+)
+
+// Column describes a column of a Table.
+type Column struct {
+	// Header is the column title
+	Header string
+	// Width is the column width in characters, 0 fits the widest value
+	Width int
+	// Align is the alignment of the values in the column
+	Align Alignment
+	// Wrap wraps values longer than Width onto several lines instead of truncating them with "…"
+	Wrap bool
+}
+
+// Table renders rows of values as a monospace grid in a MarkdownV2 code block.
+//
+// Columns that don't fit into MaxWidth are dropped, or with WrapColumns continued on the next line.
+// Rows that don't fit into MaxLength are left out and replaced by a "… N more rows" line.
+type Table struct {
+	Columns []Column
+	Rows    [][]string
+
+	// MaxWidth is the line width in characters (default: 40)
+	MaxWidth int
+	// MaxLength is the maximum length of the rendered table in UTF-16 code units (default: MaxMessageLength)
+	MaxLength int
+	// WrapColumns continues columns that don't fit into MaxWidth on the next line instead of dropping them
+	WrapColumns bool
+	// Separator is put between columns (default: " │ ")
+	Separator string
+}
+
+/*
+NewTable creates a Table with the given columns.
+*/
+func NewTable(columns ...Column) *Table {
+	return &Table{
+		Columns:   columns,
+		Rows:      make([][]string, 0),
+		MaxWidth:  defaultTableWidth,
+		MaxLength: MaxMessageLength,
+		Separator: defaultTableSeparator,
+	}
+}
+
+/*
+AddRow appends a row of values to the table. Missing values are rendered empty, extra values are ignored.
+*/
+func (t *Table) AddRow(values ...string) *Table {
+	t.Rows = append(t.Rows, values)
+	return t
+}
+
+/*
+Render returns the table as an escaped MarkdownV2 code block.
+*/
+func (t *Table) Render() string {
+	maxWidth := t.MaxWidth
+	if maxWidth <= 0 {
+		maxWidth = defaultTableWidth
+	}
+	maxLength := t.MaxLength
+	if maxLength <= 0 {
+		maxLength = MaxMessageLength
+	}
+
+	widths := t.columnWidths(maxWidth)
+	groups := t.columnGroups(widths, maxWidth)
+
+	// each column group gets its header line and the rule below it
+	var header []string
+	for _, group := range groups {
+		header = append(header, t.renderRow(t.headers(), widths, [][]int{group}, false)...)
+		header = append(header, t.renderRule(widths, group))
+	}
+
+	// reserve room for the fences and the "more rows" line
+	budget := maxLength - 2*len(codeBlockFence) - 2 - len(fmt.Sprintf(MORE_ROWS, len(t.Rows))) - 1
+
+	var out strings.Builder
+	for _, line := range header {
+		out.WriteString(escapeCode(line))
+		out.WriteString("\n")
+	}
+	length := utf16Length(out.String())
+
+	rendered := 0
+	for _, row := range t.Rows {
+		var rowOut strings.Builder
+		for _, line := range t.renderRow(row, widths, groups, true) {
+			rowOut.WriteString(escapeCode(line))
+			rowOut.WriteString("\n")
+		}
+		rowLength := utf16Length(rowOut.String())
+		if length+rowLength > budget {
+			break
+		}
+		out.WriteString(rowOut.String())
+		length += rowLength
+		rendered++
+	}
+
+	if rendered < len(t.Rows) {
+		out.WriteString(escapeCode(fmt.Sprintf(MORE_ROWS, len(t.Rows)-rendered)))
+		out.WriteString("\n")
+	}
+
+	return codeBlockFence + "\n" + out.String() + codeBlockFence
+}
+
+func (t *Table) headers() []string {
+	headers := make([]string, len(t.Columns))
+	for i, column := range t.Columns {
+		headers[i] = column.Header
+	}
+	return headers
+}
+
+// columnWidths returns the width of each column, never wider than the line.
+func (t *Table) columnWidths(maxWidth int) []int {
+	widths := make([]int, len(t.Columns))
+	for i, column := range t.Columns {
+		width := column.Width
+		if width <= 0 {
+			width = utf8.RuneCountInString(column.Header)
+			for _, row := range t.Rows {
+				if i < len(row) && utf8.RuneCountInString(row[i]) > width {
+					width = utf8.RuneCountInString(row[i])
+				}
+			}
+		}
+		if width > maxWidth {
+			width = maxWidth
+		}
+		if width < 1 {
+			width = 1
+		}
+		widths[i] = width
+	}
+	return widths
+}
+
+// columnGroups splits the columns into lines of at most maxWidth characters.
+// Without WrapColumns only the first line is kept and the other columns are dropped.
+func (t *Table) columnGroups(widths []int, maxWidth int) [][]int {
+	sepWidth := utf8.RuneCountInString(t.separator())
+	groups := make([][]int, 0)
+	current := make([]int, 0)
+	lineWidth := 0
+
+	for i, width := range widths {
+		needed := width
+		if len(current) > 0 {
+			needed += sepWidth
+		}
+		if len(current) > 0 && lineWidth+needed > maxWidth {
+			groups = append(groups, current)
+			if !t.WrapColumns {
+				return groups
+			}
+			current = make([]int, 0)
+			lineWidth = 0
+			needed = width
+		}
+		current = append(current, i)
+		lineWidth += needed
+	}
+	if len(current) > 0 {
+		groups = append(groups, current)
+	}
+	return groups
+}
+
+func (t *Table) separator() string {
+	if t.Separator == "" {
+		return defaultTableSeparator
+	}
+	return t.Separator
+}
+
+// renderRow renders the values as one line per column group, or more if values are wrapped.
+func (t *Table) renderRow(values []string, widths []int, groups [][]int, allowWrap bool) []string {
+	lines := make([]string, 0)
+	for _, group := range groups {
+		cells := make([][]string, len(group))
+		height := 1
+		for j, col := range group {
+			value := ""
+			if col < len(values) {
+				value = strings.ReplaceAll(values[col], "\n", " ")
+			}
+			if allowWrap && t.Columns[col].Wrap {
+				cells[j] = wrapText(value, widths[col])
+			} else {
+				cells[j] = []string{truncateText(value, widths[col])}
+			}
+			if len(cells[j]) > height {
+				height = len(cells[j])
+			}
+		}
+
+		for line := 0; line < height; line++ {
+			parts := make([]string, len(group))
+			for j, col := range group {
+				value := ""
+				if line < len(cells[j]) {
+					value = cells[j][line]
+				}
+				parts[j] = alignText(value, widths[col], t.Columns[col].Align)
+			}
+			lines = append(lines, strings.TrimRight(strings.Join(parts, t.separator()), " "))
+		}
+	}
+	return lines
+}
+
+func (t *Table) renderRule(widths []int, group []int) string {
+	parts := make([]string, len(group))
+	for j, col := range group {
+		parts[j] = strings.Repeat("─", widths[col])
+	}
+	rule := strings.ReplaceAll(t.separator(), "│", "┼")
+	rule = strings.ReplaceAll(rule, " ", "─")
+	return strings.Join(parts, rule)
+}
+
+func truncateText(s string, width int) string {
+	if utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:width-1]) + "…"
+}
+
+func wrapText(s string, width int) []string {
+	runes := []rune(s)
+	if len(runes) <= width {
+		return []string{s}
+	}
+	lines := make([]string, 0, len(runes)/width+1)
+	for len(runes) > width {
+		lines = append(lines, string(runes[:width]))
+		runes = runes[width:]
+	}
+	return append(lines, string(runes))
+}
+
+func alignText(s string, width int, align Alignment) string {
+	padding := width - utf8.RuneCountInString(s)
+	if padding <= 0 {
+		return s
+	}
+	switch align {
+	case AlignRight:
+		return strings.Repeat(" ", padding) + s
+	case AlignCenter:
+		left := padding / 2
+		return strings.Repeat(" ", left) + s + strings.Repeat(" ", padding-left)
+	default:
+		return s + strings.Repeat(" ", padding)
+	}
+}
+
+// utf16Length returns the length of s in UTF-16 code units, the unit of the Telegram message length limit.
+func utf16Length(s string) int {
+	length := 0
+	for _, r := range s {
+		if n := utf16.RuneLen(r); n > 0 {
+			length += n
+		} else {
+			length++
+		}
+	}
+	return length
+}
+
+// escapeCode escapes the characters that are reserved inside a MarkdownV2 code block.
+func escapeCode(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return strings.ReplaceAll(s, "`", "\\`")
+}
+
+/*
+NewTableDataResult creates a DataResult rendering the table below the title.
+The table is fitted into the message length left by the title.
+*/
+func NewTableDataResult(title string, table *Table, replyMarkup [][]button.Button, pagesCount int64) DataResult {
+	text := ""
+	if title != "" {
+		text = helper.EscapeTelegramReserved(title) + "\n"
+	}
+	if table.MaxLength <= 0 || table.MaxLength > MaxMessageLength-utf16Length(text) {
+		table.MaxLength = MaxMessageLength - utf16Length(text)
+	}
+
+	return DataResult{
+		Text:        text + table.Render(),
+		ReplyMarkup: replyMarkup,
+		PagesCount:  pagesCount,
+		Markdown:    true,
+	}
+}
@@ -0,0 +1,67 @@
+package datatable
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+const (
+	// SearchQueryKey is the filter key holding the quick search query passed to the data handler.
+	SearchQueryKey = "query"
+
+	SEARCH_CHIP = "🔎 %s"
+
+	cbCmdClearSearch = "searchclear"
+)
+
+// focus routes text messages of the chat to the quick search while the list is shown.
+func (d *DataTable) focus() {
+	if !d.quickSearch || d.questionaireManager == nil {
+		return
+	}
+	if chatID, ok := d.chatID.(int64); ok {
+		d.questionaireManager.SetFocus(chatID, d.prefix, d.handleSearchMessage)
+	}
+}
+
+// blur stops routing text messages to the quick search.
+func (d *DataTable) blur() {
+	if !d.quickSearch || d.questionaireManager == nil {
+		return
+	}
+	if chatID, ok := d.chatID.(int64); ok {
+		d.questionaireManager.RemoveFocus(chatID, d.prefix)
+	}
+}
+
+// handleSearchMessage uses the text of the message as the quick search query
+func (d *DataTable) handleSearchMessage(ctx context.Context, b *bot.Bot, update *models.Update) {
+	query := strings.TrimSpace(update.Message.Text)
+	if query == "" || strings.HasPrefix(query, "/") {
+		return
+	}
+
+	fmt.Println("[datatable.handleSearchMessage] query:", query)
+
+	// reset current page on query change
+	d.resetPage()
+	d.updateFilter(SearchQueryKey, query)
+
+	// resend the table so it stays below the user's message
+	d.deleteMessage(ctx, b)
+	if _, err := d.Show(ctx, b, d.chatID, d.currentFilter); err != nil {
+		d.onError(err)
+	}
+}
+
+// handleClearSearch removes the quick search query
+func (d *DataTable) handleClearSearch(ctx context.Context, b *bot.Bot, mes models.MaybeInaccessibleMessage) {
+	fmt.Println("[datatable.handleClearSearch] clear query")
+	d.resetPage()
+	delete(d.currentFilter, SearchQueryKey)
+	d.refresh(ctx, b)
+}
@@ -0,0 +1,209 @@
+package datatable
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+const (
+	SELECTED        = "✅"
+	UNSELECTED      = "⬜"
+	SELECT_PAGE     = "☑️ Select page"
+	UNSELECT_PAGE   = "⬜ Unselect page"
+	CLEAR_SELECTION = "✖️ Clear (%d)"
+	BULK_ACTION     = "%s (%d)"
+
+	cbPfxSelectRow      = "sel_"  // Followed by row ID, e.g., "sel_42"
+	cbPfxBulkAction     = "bulk_" // Followed by bulk action index, e.g., "bulk_0"
+	cbCmdSelectPage     = "selpage"
+	cbCmdClearSelection = "selclear"
+)
+
+// Row identifies a single record shown on the current page.
+// ID is passed to bulk action handlers, Text is used as the label of the row's selection toggle.
+type Row struct {
+	ID   string
+	Text string
+}
+
+/*
+NewRow creates a Row with the provided ID and label text.
+*/
+func NewRow(id string, text string) Row {
+	return Row{
+		ID:   id,
+		Text: text,
+	}
+}
+
+// BulkActionHandler is called with the IDs of all selected rows, in the order they were selected.
+// Returning nil clears the selection.
+type BulkActionHandler func(ctx context.Context, b *bot.Bot, mes models.MaybeInaccessibleMessage, ids []string) error
+
+type bulkAction struct {
+	text    string
+	handler BulkActionHandler
+}
+
+// selection keeps the selected row IDs across pages.
+type selection struct {
+	ids   map[string]bool
+	order []string
+}
+
+func newSelection() *selection {
+	return &selection{
+		ids:   make(map[string]bool),
+		order: make([]string, 0),
+	}
+}
+
+func (s *selection) isSelected(id string) bool {
+	return s.ids[id]
+}
+
+func (s *selection) add(id string) {
+	if s.ids[id] {
+		return
+	}
+	s.ids[id] = true
+	s.order = append(s.order, id)
+}
+
+func (s *selection) remove(id string) {
+	if !s.ids[id] {
+		return
+	}
+	delete(s.ids, id)
+	for i, selectedID := range s.order {
+		if selectedID == id {
+			s.order = append(s.order[:i], s.order[i+1:]...)
+			break
+		}
+	}
+}
+
+func (s *selection) toggle(id string) {
+	if s.ids[id] {
+		s.remove(id)
+	} else {
+		s.add(id)
+	}
+}
+
+func (s *selection) clear() {
+	s.ids = make(map[string]bool)
+	s.order = make([]string, 0)
+}
+
+func (s *selection) len() int {
+	return len(s.order)
+}
+
+// list returns a copy of the selected IDs so handlers can't mutate the selection.
+func (s *selection) list() []string {
+	ids := make([]string, len(s.order))
+	copy(ids, s.order)
+	return ids
+}
+
+/*
+SelectedIDs returns the IDs of the selected rows, in the order they were selected.
+*/
+func (d *DataTable) SelectedIDs() []string {
+	if d.selection == nil {
+		return nil
+	}
+	return d.selection.list()
+}
+
+/*
+ClearSelection removes all rows from the selection.
+*/
+func (d *DataTable) ClearSelection() {
+	if d.selection != nil {
+		d.selection.clear()
+	}
+}
+
+// pageSelected reports whether every row on the current page is selected.
+func (d *DataTable) pageSelected() bool {
+	if len(d.rows) == 0 {
+		return false
+	}
+	for _, row := range d.rows {
+		if !d.selection.isSelected(row.ID) {
+			return false
+		}
+	}
+	return true
+}
+
+// togglePage selects every row on the current page, or unselects them if they are all selected already.
+func (d *DataTable) togglePage() {
+	if d.pageSelected() {
+		for _, row := range d.rows {
+			d.selection.remove(row.ID)
+		}
+		return
+	}
+	for _, row := range d.rows {
+		d.selection.add(row.ID)
+	}
+}
+
+// handleSelectRow toggles the selection of a single row
+func (d *DataTable) handleSelectRow(ctx context.Context, b *bot.Bot, mes models.MaybeInaccessibleMessage, rowID string) {
+	fmt.Println("[datatable.handleSelectRow] toggle row:", rowID)
+	d.selection.toggle(rowID)
+	d.refresh(ctx, b)
+}
+
+// handleSelectPage toggles the selection of all rows on the current page
+func (d *DataTable) handleSelectPage(ctx context.Context, b *bot.Bot, mes models.MaybeInaccessibleMessage) {
+	fmt.Println("[datatable.handleSelectPage] toggle page")
+	d.togglePage()
+	d.refresh(ctx, b)
+}
+
+// handleClearSelection removes all rows from the selection
+func (d *DataTable) handleClearSelection(ctx context.Context, b *bot.Bot, mes models.MaybeInaccessibleMessage) {
+	fmt.Println("[datatable.handleClearSelection] clear selection")
+	d.selection.clear()
+	d.refresh(ctx, b)
+}
+
+// handleBulkAction calls the bulk action handler with the selected row IDs
+func (d *DataTable) handleBulkAction(ctx context.Context, b *bot.Bot, mes models.MaybeInaccessibleMessage, indexStr string) {
+	index, err := strconv.Atoi(indexStr)
+	if err != nil {
+		d.onError(err)
+		return
+	}
+	if index < 0 || index >= len(d.bulkActions) {
+		d.onError(fmt.Errorf("unknown bulk action: %d", index))
+		return
+	}
+
+	action := d.bulkActions[index]
+	fmt.Println("[datatable.handleBulkAction]", action.text, "selected:", d.selection.len())
+
+	if d.selection.len() > 0 {
+		if err := action.handler(ctx, b, mes, d.selection.list()); err != nil {
+			d.onError(err)
+			b.SendMessage(ctx, &bot.SendMessageParams{
+				ChatID: d.chatID,
+				Text:   err.Error(),
+			})
+		} else {
+			d.selection.clear()
+			d.InvalidateCache()
+		}
+	}
+
+	d.refresh(ctx, b)
+}
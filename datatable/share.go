@@ -0,0 +1,197 @@
+package datatable
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+const (
+	// MaxStartPayloadLength is the maximum length of a Telegram /start deep link payload.
+	MaxStartPayloadLength = 64
+
+	shareTokenSeparator = "_"
+	sharePageKey        = "p"
+	shareCursorKey      = "c"
+	shareQueryKey       = "q"
+	shareFilterPrefix   = "f."
+)
+
+var (
+	// ErrShareTokenTooLong is returned when the view state doesn't fit into a /start payload.
+	ErrShareTokenTooLong = errors.New("datatable: share token is longer than 64 characters")
+	// ErrInvalidShareToken is returned for a malformed share token.
+	ErrInvalidShareToken = errors.New("datatable: invalid share token")
+
+	tableNamePattern = regexp.MustCompile(`^[A-Za-z0-9-]{1,32}$`)
+)
+
+/*
+ShareToken returns the current view state (filters, quick search query and page) as a compact token
+usable as a /start deep link payload. The table needs a name set with WithName.
+With WithSigningKey the token is signed, so a tampered token is rejected by ShowFromStart.
+*/
+func (d *DataTable) ShareToken() (string, error) {
+	if d.name == "" {
+		return "", errors.New("datatable: ShareToken requires a table name, see WithName")
+	}
+
+	state := url.Values{}
+	if pageNum, ok := d.currentFilter["pageNum"].(int64); ok && pageNum > 1 {
+		state.Set(sharePageKey, strconv.FormatInt(pageNum, 10))
+	}
+	if cursor := d.currentCursor(); cursor != "" {
+		state.Set(shareCursorKey, cursor)
+	}
+	if query, ok := d.currentFilter[SearchQueryKey]; ok && query != nil {
+		state.Set(shareQueryKey, fmt.Sprint(query))
+	}
+	for _, key := range d.filterKeys {
+		if value, ok := d.currentFilter[key]; ok && value != nil {
+			state.Set(shareFilterPrefix+key, fmt.Sprint(value))
+		}
+	}
+
+	token := d.name
+	if len(state) > 0 || d.signer != nil {
+		// a signature follows the separator, so the name stays apart
+		token += shareTokenSeparator + base64.RawURLEncoding.EncodeToString([]byte(state.Encode()))
+	}
+	token = d.signer.Sign(token)
+	if len(token) > MaxStartPayloadLength {
+		return "", ErrShareTokenTooLong
+	}
+	return token, nil
+}
+
+/*
+DeepLink returns a t.me link opening the bot with the current view state, e.g. https://t.me/my_bot?start=orders_cD0z.
+*/
+func (d *DataTable) DeepLink(botUsername string) (string, error) {
+	token, err := d.ShareToken()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("https://t.me/%s?start=%s", strings.TrimPrefix(botUsername, "@"), token), nil
+}
+
+/*
+ShowFromStart shows the table with the view state of an incoming "/start <token>" message.
+It returns false if the message has no share token for this table, so several tables can try the same update.
+A token with a wrong signature or unknown filters returns ErrInvalidShareToken, a page past the last one shows the last page.
+*/
+func (d *DataTable) ShowFromStart(ctx context.Context, b *bot.Bot, update *models.Update) (bool, error) {
+	if update.Message == nil || d.name == "" {
+		return false, nil
+	}
+	command, payload, found := strings.Cut(strings.TrimSpace(update.Message.Text), " ")
+	if !found || (command != "/start" && !strings.HasPrefix(command, "/start@")) {
+		return false, nil
+	}
+
+	payload = strings.TrimSpace(payload)
+	if name, _, _ := strings.Cut(payload, shareTokenSeparator); name != d.name {
+		return false, nil
+	}
+	payload, err := d.signer.Verify(payload)
+	if err != nil {
+		return false, ErrInvalidShareToken
+	}
+	name, state, err := parseShareToken(payload)
+	if err != nil {
+		return false, err
+	}
+
+	fmt.Println("[datatable.ShowFromStart] restore view:", name, state)
+	if err := d.restoreViewState(state); err != nil {
+		return false, err
+	}
+	// the table is sent again below, the buttons of its old message must not drive the restored view
+	if err := d.Close(ctx); err != nil {
+		d.onError(err)
+	}
+	d.loadCurrentPage(ctx, b)
+	// the page of an old token may be gone
+	if pageNum := d.currentFilter["pageNum"].(int64); d.err == nil && !d.cursorPagination && d.pagesCount > 0 && pageNum > d.pagesCount {
+		d.currentFilter["pageNum"] = d.pagesCount
+		d.loadCurrentPage(ctx, b)
+	}
+	_, err = d.send(ctx, b, update.Message.Chat.ID)
+	return true, err
+}
+
+// parseShareToken splits a share token into the table name and the view state.
+func parseShareToken(token string) (string, url.Values, error) {
+	name, encoded, _ := strings.Cut(token, shareTokenSeparator)
+	if !tableNamePattern.MatchString(name) {
+		return "", nil, ErrInvalidShareToken
+	}
+	if encoded == "" {
+		return name, url.Values{}, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", nil, ErrInvalidShareToken
+	}
+	state, err := url.ParseQuery(string(raw))
+	if err != nil {
+		return "", nil, ErrInvalidShareToken
+	}
+	return name, state, nil
+}
+
+// restoreViewState replaces the filters and page with the shared view state.
+// A state with keys other than the page, cursor, query and the table's filter keys is rejected.
+func (d *DataTable) restoreViewState(state url.Values) error {
+	for key := range state {
+		switch {
+		case key == sharePageKey, key == shareCursorKey, key == shareQueryKey:
+		case strings.HasPrefix(key, shareFilterPrefix) && d.isFilterKey(strings.TrimPrefix(key, shareFilterPrefix)):
+		default:
+			return fmt.Errorf("%w: unknown key %q", ErrInvalidShareToken, key)
+		}
+	}
+
+	for _, key := range d.filterKeys {
+		delete(d.currentFilter, key)
+	}
+	delete(d.currentFilter, SearchQueryKey)
+	d.resetPage()
+	d.detail = nil
+
+	if pageNum, err := strconv.ParseInt(state.Get(sharePageKey), 10, 64); err == nil && pageNum > 0 {
+		d.currentFilter["pageNum"] = pageNum
+	}
+	if cursor := state.Get(shareCursorKey); cursor != "" && d.cursorPagination {
+		d.setCursor(cursor)
+	}
+	if query := state.Get(shareQueryKey); query != "" && d.quickSearch {
+		d.updateFilter(SearchQueryKey, query)
+	}
+
+	for _, key := range d.filterKeys {
+		if value := state.Get(shareFilterPrefix + key); value != "" {
+			d.updateFilter(key, value)
+		}
+	}
+	return nil
+}
+
+// isFilterKey reports whether the key is one of the table's filter keys
+func (d *DataTable) isFilterKey(key string) bool {
+	for _, filterKey := range d.filterKeys {
+		if filterKey == key {
+			return true
+		}
+	}
+	return false
+}
@@ -7,6 +7,8 @@ import (
 
 	"github.com/go-telegram/bot"
 	"github.com/go-telegram/bot/models"
+	"github.com/jkevinp/tgui/callbackdata"
+	"github.com/jkevinp/tgui/router"
 )
 
 const (
@@ -25,9 +27,13 @@ const (
 	cmdSelectYear
 )
 
-func (datePicker *DatePicker) callbackAnswer(ctx context.Context, b *bot.Bot, callbackQuery *models.CallbackQuery) {
+func (datePicker *DatePicker) callbackAnswer(ctx context.Context, b *bot.Bot, callbackQuery *models.CallbackQuery, text string) {
+	if router.Answer(ctx, text) {
+		return
+	}
 	ok, err := b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
 		CallbackQueryID: callbackQuery.ID,
+		Text:            text,
 	})
 	if err != nil {
 		datePicker.onError(err)
@@ -39,7 +45,12 @@ func (datePicker *DatePicker) callbackAnswer(ctx context.Context, b *bot.Bot, ca
 }
 
 func (datePicker *DatePicker) callback(ctx context.Context, b *bot.Bot, update *models.Update) {
-	st := datePicker.decodeState(update.CallbackQuery.Data)
+	st, err := datePicker.decodeState(update.CallbackQuery.Data)
+	if err != nil {
+		datePicker.onError(fmt.Errorf("wrong callback data %s, %w", update.CallbackQuery.Data, err))
+		datePicker.callbackAnswer(ctx, b, update.CallbackQuery, callbackdata.InvalidButtonText)
+		return
+	}
 
 	switch st.cmd {
 	case cmdYearClick:
@@ -57,7 +68,7 @@ func (datePicker *DatePicker) callback(ctx context.Context, b *bot.Bot, update *
 			if errDelete != nil {
 				datePicker.onError(fmt.Errorf("failed to delete message onSelect: %w", errDelete))
 			}
-			b.UnregisterHandler(datePicker.callbackHandlerID)
+			datePicker.unregister(b)
 		}
 		datePicker.onSelect(ctx, b, update.CallbackQuery.Message, time.Date(datePicker.year, datePicker.month, st.param, 0, 0, 0, 0, time.Local))
 	case cmdCancel:
@@ -69,7 +80,7 @@ func (datePicker *DatePicker) callback(ctx context.Context, b *bot.Bot, update *
 			if errDelete != nil {
 				datePicker.onError(fmt.Errorf("failed to delete message onCancel: %w", errDelete))
 			}
-			b.UnregisterHandler(datePicker.callbackHandlerID)
+			datePicker.unregister(b)
 		}
 		datePicker.onCancel(ctx, b, update.CallbackQuery.Message)
 	case cmdPrevYears:
@@ -102,7 +113,7 @@ func (datePicker *DatePicker) callback(ctx context.Context, b *bot.Bot, update *
 		datePicker.onError(fmt.Errorf("unknown command: %d", st.cmd))
 	}
 
-	datePicker.callbackAnswer(ctx, b, update.CallbackQuery)
+	datePicker.callbackAnswer(ctx, b, update.CallbackQuery, "")
 }
 
 func (datePicker *DatePicker) showSelectMonth(ctx context.Context, b *bot.Bot, mes models.MaybeInaccessibleMessage) {
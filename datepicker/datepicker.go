@@ -8,6 +8,8 @@ import (
 
 	"github.com/go-telegram/bot"
 	"github.com/go-telegram/bot/models"
+	"github.com/jkevinp/tgui/callbackdata"
+	"github.com/jkevinp/tgui/router"
 )
 
 type DatesMode int
@@ -35,6 +37,8 @@ type DatePicker struct {
 	onSelect        OnSelectHandler
 	onCancel        OnCancelHandler
 	onError         OnErrorHandler
+	router          *router.Router
+	signer          *callbackdata.Signer
 
 	// current date
 	month time.Month
@@ -67,11 +71,30 @@ func New(b *bot.Bot, onSelect OnSelectHandler, opts ...Option) *DatePicker {
 		opt(datePicker)
 	}
 
-	datePicker.callbackHandlerID = b.RegisterHandler(bot.HandlerTypeCallbackQueryData, datePicker.prefix, bot.MatchTypePrefix, datePicker.callback)
+	if datePicker.router != nil {
+		datePicker.callbackHandlerID, datePicker.prefix = datePicker.router.Handle("dp", datePicker.callback)
+	} else {
+		datePicker.callbackHandlerID = b.RegisterHandler(bot.HandlerTypeCallbackQueryData, datePicker.prefix, bot.MatchTypePrefix, datePicker.callback)
+	}
 
 	return datePicker
 }
 
+// Unregister removes the widget's handler, for a picker kept after select or cancel
+// with NoDeleteAfterSelect or NoDeleteAfterCancel once it is no longer shown
+func (datePicker *DatePicker) Unregister(b *bot.Bot) {
+	datePicker.unregister(b)
+}
+
+// unregister removes the widget's handler from the router or the bot
+func (datePicker *DatePicker) unregister(b *bot.Bot) {
+	if datePicker.router != nil {
+		datePicker.router.Remove(datePicker.callbackHandlerID)
+		return
+	}
+	b.UnregisterHandler(datePicker.callbackHandlerID)
+}
+
 // Prefix returns the prefix of the widget
 func (datePicker *DatePicker) Prefix() string {
 	return datePicker.prefix
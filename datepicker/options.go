@@ -2,6 +2,9 @@ package datepicker
 
 import (
 	"time"
+
+	"github.com/jkevinp/tgui/callbackdata"
+	"github.com/jkevinp/tgui/router"
 )
 
 type Option func(dp *DatePicker)
@@ -81,16 +84,33 @@ func WithPrefix(s string) Option {
 	}
 }
 
-// NoDeleteAfterSelect is a keyboard option that prevents the hide keyboard after select
+// NoDeleteAfterSelect is a keyboard option that prevents the hide keyboard after select.
+// The handler stays registered, call Unregister when the picker is done
 func NoDeleteAfterSelect() Option {
 	return func(dp *DatePicker) {
 		dp.deleteOnSelect = false
 	}
 }
 
-// NoDeleteAfterCancel is a keyboard option that prevents the hide keyboard after cancel
+// NoDeleteAfterCancel is a keyboard option that prevents the hide keyboard after cancel.
+// The handler stays registered, call Unregister when the picker is done
 func NoDeleteAfterCancel() Option {
 	return func(dp *DatePicker) {
 		dp.deleteOnCancel = false
 	}
 }
+
+// WithRouter dispatches the widget's callbacks through the router instead of registering its own bot handler.
+// The router assigns the prefix, so WithPrefix has no effect.
+func WithRouter(r *router.Router) Option {
+	return func(dp *DatePicker) {
+		dp.router = r
+	}
+}
+
+// WithSigningKey signs the callback data with the key, clicks with tampered data are rejected.
+func WithSigningKey(key []byte) Option {
+	return func(dp *DatePicker) {
+		dp.signer = callbackdata.NewSigner(key)
+	}
+}
@@ -4,10 +4,15 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 )
 
 const (
 	queryDataSeparator = ":"
+
+	// minYear and maxYear bound the years a callback can select
+	minYear = 1
+	maxYear = 9999
 )
 
 type state struct {
@@ -21,28 +26,59 @@ func (datePicker *DatePicker) encodeState(st state) string {
 	parts = append(parts, strconv.Itoa(st.cmd))
 	parts = append(parts, strconv.Itoa(st.param))
 
-	return datePicker.prefix + strings.Join(parts, queryDataSeparator)
+	return datePicker.signer.Sign(datePicker.prefix + strings.Join(parts, queryDataSeparator))
 }
 
-func (datePicker *DatePicker) decodeState(queryData string) state {
+func (datePicker *DatePicker) decodeState(queryData string) (state, error) {
+	queryData, err := datePicker.signer.Verify(queryData)
+	if err != nil {
+		return state{}, err
+	}
+
 	parts := strings.Split(strings.TrimPrefix(queryData, datePicker.prefix), queryDataSeparator)
 
 	if len(parts) != 2 {
-		panic(fmt.Errorf("invalid data format, expected 2 parts, got %d", len(parts)))
+		return state{}, fmt.Errorf("invalid data format, expected 2 parts, got %d", len(parts))
 	}
 
 	cmd, err := strconv.Atoi(parts[0])
 	if err != nil {
-		panic(fmt.Errorf("invalid command: %s", err))
+		return state{}, fmt.Errorf("invalid command: %s", err)
 	}
 
 	param, err := strconv.Atoi(parts[1])
 	if err != nil {
-		panic(fmt.Errorf("invalid parameter: %s", err))
+		return state{}, fmt.Errorf("invalid parameter: %s", err)
 	}
 
-	return state{
+	st := state{
 		cmd:   cmd,
 		param: param,
 	}
+
+	return st, datePicker.validateState(st)
+}
+
+// validateState checks the parameter of the command against the current month and year
+func (datePicker *DatePicker) validateState(st state) error {
+	switch st.cmd {
+	case cmdMonthClick:
+		if st.param < 1 || st.param > 12 {
+			return fmt.Errorf("invalid month: %d", st.param)
+		}
+	case cmdDayClick:
+		daysInMonth := time.Date(datePicker.year, datePicker.month+1, 0, 0, 0, 0, 0, time.Local).Day()
+		if st.param < 1 || st.param > daysInMonth {
+			return fmt.Errorf("invalid day: %d", st.param)
+		}
+	case cmdYearClick, cmdPrevYears, cmdNextYears:
+		if st.param < minYear || st.param > maxYear {
+			return fmt.Errorf("invalid year: %d", st.param)
+		}
+	default:
+		if st.cmd < cmdPrevMonth || st.cmd > cmdSelectYear {
+			return fmt.Errorf("unknown command: %d", st.cmd)
+		}
+	}
+	return nil
 }
@@ -8,6 +8,8 @@ import (
 
 	"github.com/go-telegram/bot"
 	"github.com/go-telegram/bot/models"
+	"github.com/jkevinp/tgui/callbackdata"
+	"github.com/jkevinp/tgui/router"
 )
 
 type OnErrorHandler func(err error)
@@ -18,6 +20,8 @@ type Dialog struct {
 	onError OnErrorHandler
 	nodes   []Node
 	inline  bool
+	router  *router.Router
+	signer  *callbackdata.Signer
 
 	callbackHandlerID string
 }
@@ -33,7 +37,11 @@ func New(b *bot.Bot, nodes []Node, opts ...Option) *Dialog {
 		opt(p)
 	}
 
-	p.callbackHandlerID = b.RegisterHandler(bot.HandlerTypeCallbackQueryData, p.prefix, bot.MatchTypePrefix, p.callback)
+	if p.router != nil {
+		p.callbackHandlerID, p.prefix = p.router.Handle("dg", p.callback)
+	} else {
+		p.callbackHandlerID = b.RegisterHandler(bot.HandlerTypeCallbackQueryData, p.prefix, bot.MatchTypePrefix, p.callback)
+	}
 
 	return p
 }
@@ -52,7 +60,7 @@ func (d *Dialog) showNode(ctx context.Context, b *bot.Bot, chatID any, node Node
 		ChatID:      chatID,
 		Text:        node.Text,
 		ParseMode:   models.ParseModeMarkdown,
-		ReplyMarkup: node.buildKB(d.prefix),
+		ReplyMarkup: node.buildKB(d.prefix, d.signer),
 	}
 
 	return b.SendMessage(ctx, params)
@@ -67,29 +75,44 @@ func (d *Dialog) Show(ctx context.Context, b *bot.Bot, chatID any, nodeID string
 	return d.showNode(ctx, b, chatID, node)
 }
 
-func (d *Dialog) callback(ctx context.Context, b *bot.Bot, update *models.Update) {
-	ok, err := b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{CallbackQueryID: update.CallbackQuery.ID})
+func (d *Dialog) callbackAnswer(ctx context.Context, b *bot.Bot, callbackQuery *models.CallbackQuery, text string) {
+	if router.Answer(ctx, text) {
+		return
+	}
+	ok, err := b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{CallbackQueryID: callbackQuery.ID, Text: text})
 	if err != nil {
 		d.onError(err)
 	}
 	if !ok {
 		d.onError(fmt.Errorf("failed to answer callback query"))
 	}
+}
+
+func (d *Dialog) callback(ctx context.Context, b *bot.Bot, update *models.Update) {
+	data, errVerify := d.signer.Verify(update.CallbackQuery.Data)
+	if errVerify != nil {
+		d.onError(fmt.Errorf("%w, %s", errVerify, update.CallbackQuery.Data))
+		d.callbackAnswer(ctx, b, update.CallbackQuery, callbackdata.InvalidButtonText)
+		return
+	}
 
-	nodeID := strings.TrimPrefix(update.CallbackQuery.Data, d.prefix)
+	nodeID := strings.TrimPrefix(data, d.prefix)
 	node, ok := d.findNode(nodeID)
 	if !ok {
 		d.onError(fmt.Errorf("failed to find node with id %s", nodeID))
+		d.callbackAnswer(ctx, b, update.CallbackQuery, callbackdata.InvalidButtonText)
 		return
 	}
 
+	d.callbackAnswer(ctx, b, update.CallbackQuery, "")
+
 	if d.inline {
 		_, errEdit := b.EditMessageText(ctx, &bot.EditMessageTextParams{
 			ChatID:      update.CallbackQuery.Message.Message.Chat.ID,
 			MessageID:   update.CallbackQuery.Message.Message.ID,
 			Text:        node.Text,
 			ParseMode:   models.ParseModeMarkdown,
-			ReplyMarkup: node.buildKB(d.prefix),
+			ReplyMarkup: node.buildKB(d.prefix, d.signer),
 		})
 		if errEdit != nil {
 			d.onError(errEdit)
@@ -101,7 +124,7 @@ func (d *Dialog) callback(ctx context.Context, b *bot.Bot, update *models.Update
 		ChatID:      update.CallbackQuery.Message.Message.Chat.ID,
 		Text:        node.Text,
 		ParseMode:   models.ParseModeMarkdown,
-		ReplyMarkup: node.buildKB(d.prefix),
+		ReplyMarkup: node.buildKB(d.prefix, d.signer),
 	})
 	if errSend != nil {
 		d.onError(errSend)
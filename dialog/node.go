@@ -2,6 +2,7 @@ package dialog
 
 import (
 	"github.com/go-telegram/bot/models"
+	"github.com/jkevinp/tgui/callbackdata"
 )
 
 type Button struct {
@@ -16,7 +17,7 @@ type Node struct {
 	Keyboard [][]Button
 }
 
-func (n Node) buildKB(prefix string) models.ReplyMarkup {
+func (n Node) buildKB(prefix string, signer *callbackdata.Signer) models.ReplyMarkup {
 	if len(n.Keyboard) == 0 {
 		return nil
 	}
@@ -32,7 +33,7 @@ func (n Node) buildKB(prefix string) models.ReplyMarkup {
 			if btn.URL != "" {
 				b.URL = btn.URL
 			} else {
-				b.CallbackData = prefix + btn.NodeID
+				b.CallbackData = signer.Sign(prefix + btn.NodeID)
 			}
 			kbRow = append(kbRow, b)
 		}
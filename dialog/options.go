@@ -1,5 +1,10 @@
 package dialog
 
+import (
+	"github.com/jkevinp/tgui/callbackdata"
+	"github.com/jkevinp/tgui/router"
+)
+
 type Option func(d *Dialog)
 
 func Inline() Option {
@@ -14,3 +19,18 @@ func WithPrefix(s string) Option {
 		w.prefix = s
 	}
 }
+
+// WithRouter dispatches the widget's callbacks through the router instead of registering its own bot handler.
+// The router assigns the prefix, so WithPrefix has no effect.
+func WithRouter(r *router.Router) Option {
+	return func(d *Dialog) {
+		d.router = r
+	}
+}
+
+// WithSigningKey is a dialog option that signs the callback data with the key, clicks with tampered data are rejected.
+func WithSigningKey(key []byte) Option {
+	return func(d *Dialog) {
+		d.signer = callbackdata.NewSigner(key)
+	}
+}
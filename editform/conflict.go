@@ -0,0 +1,142 @@
+package editform
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/jkevinp/tgui/button"
+)
+
+const (
+	TEXT_CONFLICT        = "⚠️ The record was changed by someone else since the form was opened."
+	TEXT_CONFLICT_FIELDS = "Changed by both:"
+	TEXT_FORMAT_CONFLICT = "%s: yours %v, theirs %v"
+)
+
+// ConflictError is returned by a done handler when the record was saved by someone else
+// since the version the form is based on, see SetVersion.
+// Current is the saved record, a struct of the type passed to New or a pointer to one, and Version its version.
+type ConflictError struct {
+	Version string
+	Current any
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("the record was changed, current version %s", e.Version)
+}
+
+// conflict is the saved record of a ConflictError, by JSON key like the form data
+type conflict struct {
+	version string
+	current reflect.Value
+	data    map[string]interface{}
+}
+
+// SetVersion sets the version or etag of the record the form is based on.
+// The done handlers compare it with the saved version and return a ConflictError when it changed.
+func (f *EditForm) SetVersion(version string) *EditForm {
+	f.version = version
+	return f
+}
+
+// Version returns the version of the record the form is based on, updated by reload or overwrite after a conflict.
+func (f *EditForm) Version() string {
+	return f.version
+}
+
+// newConflict reads the saved record of a conflict, it fails if it is not of the type passed to New
+func (f *EditForm) newConflict(e *ConflictError) (*conflict, error) {
+	current := reflect.ValueOf(e.Current)
+	if current.Kind() == reflect.Ptr && !current.IsNil() {
+		current = current.Elem()
+	}
+	if !f.target.IsValid() || !current.IsValid() || current.Type() != f.target.Type() {
+		return nil, fmt.Errorf("%w: the current record is not a %s", e, f.target.Type())
+	}
+
+	c := conflict{version: e.Version, current: current, data: make(map[string]interface{})}
+	for _, field := range f.fields {
+		c.data[field.Key] = current.FieldByIndex(field.Index).Interface()
+	}
+	return &c, nil
+}
+
+// conflictingFields returns the fields changed both in the form and in the saved record, to different values
+func (f *EditForm) conflictingFields() []field {
+	conflicting := make([]field, 0)
+	for _, field := range f.changedFields() {
+		theirs := f.conflict.data[field.Key]
+		if !reflect.DeepEqual(f.initialData[field.Key], theirs) && !reflect.DeepEqual(f.data[field.Key], theirs) {
+			conflicting = append(conflicting, field)
+		}
+	}
+	return conflicting
+}
+
+// conflictText lists the value of each conflicting field in the form and in the saved record
+func (f *EditForm) conflictText() string {
+	lines := []string{f.text, TEXT_CONFLICT}
+	conflicting := f.conflictingFields()
+	if len(conflicting) > 0 {
+		lines = append(lines, TEXT_CONFLICT_FIELDS)
+	}
+	for _, field := range conflicting {
+		lines = append(lines, fmt.Sprintf(TEXT_FORMAT_CONFLICT, field.DisplayLabel(),
+			f.reviewValue(field, f.data[field.Key]), f.reviewValue(field, f.conflict.data[field.Key])))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// conflictControls returns the reload, overwrite and cancel buttons of a conflict
+func (f *EditForm) conflictControls() *button.ButtonGrid {
+	return button.NewBuilder().Row().Add(button.Button{
+		Text:         "🔄 Reload and reapply my changes",
+		CallbackData: f.callbackPrefix() + "reload",
+		OnClick:      f.editCallback,
+	}).Row().Add(button.Button{
+		Text:         "⚠️ Overwrite",
+		CallbackData: f.callbackPrefix() + "overwrite",
+		OnClick:      f.editCallback,
+	}).Row().Add(button.Button{
+		Text:         "❌ Cancel",
+		CallbackData: f.callbackPrefix() + "cancel",
+		OnClick:      f.editCallback,
+	})
+}
+
+// reload bases the form on the saved record of the conflict and applies the changes made in the form again
+func (f *EditForm) reload() {
+	patch := f.patch()
+
+	f.target.Set(f.conflict.current)
+	for key, value := range f.conflict.data {
+		f.initialData[key] = value
+		f.data[key] = value
+	}
+	for key, value := range patch {
+		f.data[key] = value
+	}
+	f.version = f.conflict.version
+	f.conflict = nil
+}
+
+// resolve handles the reload and overwrite commands of a conflict, it returns false for other commands.
+// Reload shows the form again to be checked and saved, overwrite saves the values of the form at once.
+func (f *EditForm) resolve(ctx context.Context, command string) bool {
+	if f.conflict == nil || (command != "reload" && command != "overwrite") {
+		return false
+	}
+
+	if command == "reload" {
+		f.reload()
+		f.Show(ctx)
+		return true
+	}
+
+	f.version = f.conflict.version
+	f.conflict = nil
+	f.submit(ctx)
+	return true
+}
@@ -2,17 +2,20 @@ package editform
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"reflect"
 	"strings"
+	"time"
 
 	"github.com/go-telegram/bot"
 	"github.com/go-telegram/bot/models"
 	"github.com/jkevinp/tgui/button"
-	"github.com/jkevinp/tgui/keyboard/inline"
+	"github.com/jkevinp/tgui/callbackdata"
+	"github.com/jkevinp/tgui/datepicker"
 	"github.com/jkevinp/tgui/parser"
 	"github.com/jkevinp/tgui/questionaire"
+	"github.com/jkevinp/tgui/router"
 )
 
 const (
@@ -38,8 +41,6 @@ type EditForm struct {
 
 	initialData map[string]interface{}
 
-	targetStructTags map[string]map[string]string
-
 	choices map[string][][]button.Button
 
 	stringFormatter   map[string]func(string) (string, error) //(key,value) return formatted value string
@@ -48,9 +49,48 @@ type EditForm struct {
 	chatID any
 
 	onCancelHandler func()
+
+	callbackHandlerID string
+	router            *router.Router
+	routePrefix       string // Prefix assigned by the router, see callbackPrefix
+
+	codec      *callbackdata.Encoder
+	signingKey []byte
+
+	target             reflect.Value // Copy of the target struct, the base of the typed result
+	fields             []field       // Editable fields of the target struct in field order
+	onDoneTypedHandler OnDoneTypedHandler
+
+	err error // Error of the tg tags of the target struct, returned by Show
+
+	group      string // Group tag of the open sub-page, empty for the main page
+	page       int    // Page of the open group or main page, from 0
+	parentPage int    // Page of the main page the open group was opened from
+	pageSize   int    // Field and group buttons per page, see SetPageSize
+	reviewing  bool   // The review of the changes is shown instead of the fields
+
+	path  []string                            // Keys of the open nested struct or slice, empty for the target struct
+	trail []location                          // Pages left for each key of the path, restored by Back
+	specs map[reflect.Type][]parser.FieldSpec // Specs of the nested struct types
+
+	validators map[string]Validator // Validators by path, see SetValidator
+	submitted  bool                 // Done was clicked, the errors of all fields are shown
+	errors     []fieldError         // Errors shown in the last built page
+
+	msgID      int        // ID of the form message, edited by every Show
+	finishMode FinishMode // What happens to the form message on Done and Cancel
+	doneErr    error      // Error of the done handlers, shown once in the form text
+
+	version  string    // Version of the record the form is based on, see SetVersion
+	conflict *conflict // Saved record of a ConflictError, shown instead of the fields until resolved
 }
 
-type OnDoneEditHandler func(map[string]interface{}) error
+// OnDoneEditHandler receives the values by JSON key, typed like the fields of the target struct,
+// and the patch holding just the changed values.
+type OnDoneEditHandler func(data map[string]interface{}, patch map[string]interface{}) error
+
+// OnDoneTypedHandler receives a pointer to a copy of the target struct with the edited values.
+type OnDoneTypedHandler func(result any) error
 
 func (f *EditForm) SetFormatter(key string, formatFunc func(string) (string, error), transformFunc func(string) (string, error)) *EditForm {
 	f.stringFormatter[key] = formatFunc
@@ -58,10 +98,47 @@ func (f *EditForm) SetFormatter(key string, formatFunc func(string) (string, err
 	return f
 }
 
+// SetOnDoneTypedHandler sets the handler receiving a typed copy of the target struct on done,
+// e.g. a *User for a User target. It runs after the OnDoneEditHandler passed to New.
+func (f *EditForm) SetOnDoneTypedHandler(handler OnDoneTypedHandler) *EditForm {
+	f.onDoneTypedHandler = handler
+	return f
+}
+
 func (f *EditForm) SetOnCancelHandler(handler func()) *EditForm {
 	f.onCancelHandler = handler
 	return f
 }
+
+// SetOverflowStore sets the store for edit buttons of keys too long for callback data (default: in memory).
+func (f *EditForm) SetOverflowStore(store callbackdata.Store) *EditForm {
+	f.codec = callbackdata.NewEncoder(store)
+	if f.signingKey != nil {
+		f.codec.WithSigner(callbackdata.NewSigner(f.signingKey))
+	}
+	return f
+}
+
+// SetSigningKey signs the callback data of the form and its value questions with the key,
+// clicks with tampered data are answered as invalid.
+func (f *EditForm) SetSigningKey(key []byte) *EditForm {
+	f.signingKey = key
+	f.codec.WithSigner(callbackdata.NewSigner(key))
+	return f
+}
+
+// SetRouter dispatches the form's callbacks through the router instead of registering its own bot handler.
+func (f *EditForm) SetRouter(r *router.Router) *EditForm {
+	f.router = r
+	return f
+}
+
+// New creates a form editing the exported fields of targetStruct, in the order of the order tag and then declaration order.
+// Fields with a group tag are edited on a sub-page of the group, long pages are split, see SetPageSize.
+// The editor of a field depends on its type: text and number input, a toggle for bools,
+// a datepicker for time.Time and choices for types implementing Enum.
+// Nested structs are edited on sub-pages, slices on list pages and slices of strings or choices as tags.
+// Maps are kept unchanged.
 func New(
 	b *bot.Bot, //bot instance
 	text string, // edit form text
@@ -91,47 +168,44 @@ func New(
 		chatID:            chatID,
 		stringFormatter:   make(map[string]func(string) (string, error)),
 		stringTransformer: make(map[string]func(string) (string, error)),
+		codec:             callbackdata.NewEncoder(callbackdata.NewMemoryStore()),
+		pageSize:          DefaultPageSize,
+		specs:             make(map[reflect.Type][]parser.FieldSpec),
+		validators:        make(map[string]Validator),
 	}
 
-	parsedTags, err := parser.ParseTGTags(targetStruct)
-
-	fmt.Println("parsedTags:", parsedTags, err)
-
-	f.targetStructTags = parsedTags
-
-	dataBytes, err := json.Marshal(targetStruct)
-
+	specs, err := parser.ParseFieldSpecs(targetStruct)
 	if err != nil {
-		fmt.Println(err)
+		fmt.Println("[editform] tags:", err)
+		f.err = err
+		return &f
 	}
 
-	if err := json.Unmarshal(dataBytes, &f.data); err != nil {
-		fmt.Println(err)
+	target := reflect.ValueOf(targetStruct)
+	if target.Kind() == reflect.Ptr {
+		target = target.Elem()
+	}
+	if target.Kind() != reflect.Struct {
+		fmt.Println("[editform] target is not a struct:", target.Kind())
+		return &f
 	}
 
-	for key, val := range f.data {
-		fmt.Println("[editform] key:", key, "val:", val, "type:", reflect.TypeOf(val))
-		//add cancel for choices
-		btnCancel := []button.Button{
-			{
-				Text:         "❌ Cancel",
-				CallbackData: prefix + "cancel_" + key,
-				OnClick: func(ctx context.Context, b *bot.Bot, mes models.MaybeInaccessibleMessage, callbackData []byte) {
-					fmt.Println("[EditForm] cancel choice for key:", key)
-					b.SendMessage(ctx, &bot.SendMessageParams{
-						ChatID: mes.Message.Chat.ID,
-						Text:   "Cancelled choice for " + key,
-					})
-				},
-			},
-		}
+	// an addressable copy, the target itself is never modified
+	f.target = reflect.New(target.Type()).Elem()
+	f.target.Set(target)
+	f.fields = parseFields(specs, "")
+	if err := f.checkNested(f.fields, map[reflect.Type]bool{target.Type(): true}); err != nil {
+		fmt.Println("[editform] tags:", err)
+		f.err = err
+		return &f
+	}
 
-		if f.choices[key] != nil {
-			f.choices[key] = append(f.choices[key], btnCancel)
-		} else {
-			f.choices[key] = [][]button.Button{btnCancel}
-		}
+	for _, field := range f.fields {
+		key := field.Key
+		f.data[key] = f.target.FieldByIndex(field.Index).Interface()
+		fmt.Println("[editform] key:", key, "type:", field.Type)
 
+		f.choices[key] = f.buildChoices(field, f.choices[key])
 	}
 
 	for key, val := range f.data {
@@ -141,135 +215,457 @@ func New(
 	return &f
 }
 
+// buildChoices returns the choices of the question of a field: the Enum values, the choices passed to New and a cancel button
+func (f *EditForm) buildChoices(field field, choices [][]button.Button) [][]button.Button {
+	key := field.path
+
+	//add cancel for choices
+	btnCancel := []button.Button{
+		{
+			Text:         "❌ Cancel",
+			CallbackData: f.prefix + "cancel_" + key,
+			OnClick: func(ctx context.Context, b *bot.Bot, mes models.MaybeInaccessibleMessage, callbackData []byte) {
+				fmt.Println("[EditForm] cancel choice for key:", key)
+				b.SendMessage(ctx, &bot.SendMessageParams{
+					ChatID: mes.Message.Chat.ID,
+					Text:   "Cancelled choice for " + key,
+				})
+			},
+		},
+	}
+
+	if field.kind == kindEnum {
+		enumChoices := button.NewBuilder()
+		for _, value := range field.enumValues {
+			enumChoices.SingleChoiceWithData(value, value)
+		}
+		choices = append(enumChoices.Build(), choices...)
+	}
+
+	return append(choices, btnCancel)
+}
+
+// fieldChoices returns the choices of the question of a field, built on demand for nested fields
+func (f *EditForm) fieldChoices(field field) [][]button.Button {
+	if field.path == field.Key {
+		return f.choices[field.Key]
+	}
+	return f.buildChoices(field, f.choices[field.path])
+}
+
+// rebuildControls builds the buttons of a conflict, of the review, or of the current page of the open nested field, group or main page
 func (f *EditForm) rebuildControls() {
 	editForm := button.NewBuilder()
 
-	for key, _ := range f.data {
+	f.errors = f.visibleErrors()
+
+	if f.conflict != nil {
+		f.buttons = f.conflictControls().Build()
+		return
+	}
+	if f.reviewing && len(f.changedFields()) == 0 {
+		// the last change was reverted
+		f.reviewing = false
+	}
+	if f.reviewing {
+		editForm = f.reviewControls()
+		f.buttons = f.addDoneRow(editForm).Build()
+		return
+	}
+
+	if _, ok := f.openField(); !ok && len(f.path) > 0 {
+		// the nested field is gone, e.g. a removed item
+		f.path, f.trail = nil, nil
+	}
+
+	entries := f.pageEntries()
+	if len(entries) == 0 && f.group != "" {
+		// the group has no editable fields, e.g. a stale group button
+		f.group, f.page = "", f.parentPage
+		entries = f.pageEntries()
+	}
+
+	pages := f.pagesCount(len(entries))
+	if f.page < 0 || f.page >= pages {
+		f.page = 0
+	}
+	from := f.page * f.pageSize
+	to := from + f.pageSize
+	if to > len(entries) {
+		to = len(entries)
+	}
 
-		tgTags := f.targetStructTags[key]
-		if tgTags["noedit"] == "true" {
-			fmt.Println("[editform] skipping key:", key)
+	for _, entry := range entries[from:to] {
+		if entry.group != "" {
+			editForm.Row().Add(f.groupButton(entry.group))
 			continue
 		}
 
-		fmtToUse := TEXT_FORMAT
+		if entry.row != nil {
+			editForm.Row().Add(entry.row...)
+			continue
+		}
 
-		if f.initialData[key] != f.data[key] {
-			fmtToUse = TEXT_FORMAT_EDITED
+		btn, err := f.fieldButton(entry.field)
+		if err != nil {
+			f.botInstance.SendMessage(context.Background(), &bot.SendMessageParams{
+				ChatID: f.chatID,
+				Text:   err.Error(),
+			})
+			return
 		}
+		editForm.Row().Add(btn)
+	}
 
-		fmt.Println("[editform] adding key:", key)
+	if add := f.addButton(); add != nil {
+		editForm.Row().Add(add...)
+	}
+	if nav := f.navigationRow(pages); nav != nil {
+		editForm.Row().Add(nav...)
+	}
+	if f.group != "" || len(f.path) > 0 {
+		editForm.Row().Add(button.Button{
+			Text:         "‹ Back",
+			CallbackData: f.callbackPrefix() + "back",
+			OnClick:      f.editCallback,
+		})
+	}
+	if review := f.reviewButton(); review != nil {
+		editForm.Row().Add(review...)
+	}
 
-		value := f.data[key]
-		var err error
+	f.buttons = f.addDoneRow(editForm).Build()
+}
 
-		if f.stringFormatter[key] != nil {
-			value, err = f.stringFormatter[key](fmt.Sprintf("%v", value))
+// fieldButton returns the button editing the field, or opening the page of a nested struct or slice
+func (f *EditForm) fieldButton(field field) (button.Button, error) {
+	edited := !reflect.DeepEqual(f.valueIn(f.initialData, field.path), f.value(field.path))
 
-			if err != nil {
-				f.botInstance.SendMessage(context.Background(), &bot.SendMessageParams{
-					ChatID: f.chatID,
-					Text:   err.Error(),
-				})
-				return
-			}
+	fmt.Println("[editform] adding key:", field.path)
 
-			fmt.Println("[editform] adding formatter for key:", key, "new value:", value)
+	var text string
+	if field.isContainer() {
+		text = f.nestedText(field)
+		if edited {
+			text = "🆕 " + text
+		}
+	} else {
+		value, err := f.formatValue(field, f.value(field.path))
+		if err != nil {
+			return button.Button{}, err
 		}
 
-		editForm.Row().Add(button.Button{
-			Text:         fmt.Sprintf(fmtToUse, key, value),
-			CallbackData: f.prefix + "edit_" + key,
-			OnClick:      f.editCallback,
-		})
+		fmtToUse := TEXT_FORMAT
+		if edited {
+			fmtToUse = TEXT_FORMAT_EDITED
+		}
+		text = fmt.Sprintf(fmtToUse, field.DisplayLabel(), value)
+	}
+	if f.hasError(field.path) {
+		text = fmt.Sprintf(TEXT_FORMAT_INVALID, text)
 	}
 
-	editForm.Row().Add(button.Button{
+	return button.Button{
+		Text:         text,
+		CallbackData: f.callbackPrefix() + f.fieldCommand(field),
+		OnClick:      f.editCallback,
+	}, nil
+}
+
+// addDoneRow adds the Done and Cancel buttons of the form
+func (f *EditForm) addDoneRow(editForm *button.ButtonGrid) *button.ButtonGrid {
+	return editForm.Row().Add(button.Button{
 		Text:         "✅ Done",
-		CallbackData: f.prefix + "done",
+		CallbackData: f.callbackPrefix() + "done",
 		OnClick:      f.editCallback,
 	}).Add(button.Button{
 		Text:         "❌ Cancel",
-		CallbackData: f.prefix + "cancel",
+		CallbackData: f.callbackPrefix() + "cancel",
 		OnClick:      f.editCallback,
 	})
-
-	f.buttons = editForm.Build()
 }
 
 func (f *EditForm) editCallback(ctx context.Context, b *bot.Bot, mes models.MaybeInaccessibleMessage, callbackData []byte) {
 	fmt.Println("[EditForm.editCallback] ", f.prefix, "->", string(callbackData))
 
-	command := strings.TrimPrefix(string(callbackData), f.prefix)
+	command := strings.TrimPrefix(string(callbackData), f.callbackPrefix())
 
 	switch command {
 	case "done":
-		fmt.Println("[EditForm.editCallback] done", f.data)
-		if err := f.OnDoneEditHandler(f.data); err != nil {
-			fmt.Println(err)
-			b.SendMessage(ctx, &bot.SendMessageParams{
-				ChatID: mes.Message.Chat.ID,
-				Text:   err.Error(),
-			})
-		}
+		f.submit(ctx)
 	case "cancel":
 		fmt.Println("[EditForm.editCallback] cancel")
+		f.unregister()
+		f.finish(ctx, false)
 		if f.onCancelHandler != nil {
 			f.onCancelHandler()
 		}
 
 	default:
-		if strings.HasPrefix(command, "edit_") {
+		if f.resolve(ctx, command) {
+			return
+		}
+		if f.review(command) {
+			f.Show(ctx)
+			return
+		}
+		if handled, err := f.nested(command); handled || f.navigate(command) {
+			if err != nil {
+				fmt.Println("[EditForm.editCallback]", err)
+			}
+			f.Show(ctx)
+			return
+		}
+
+		switch {
+		case command == "add":
+			f.addItem(ctx, b, mes)
+		case strings.HasPrefix(command, "edit_"):
 			fmt.Println("[EditForm.editCallback] edit", command)
-			key := strings.TrimPrefix(command, "edit_")
+			field, ok := f.fieldAt(strings.TrimPrefix(command, "edit_"))
+			if !ok {
+				fmt.Println("[EditForm.editCallback] unknown field:", command)
+				return
+			}
+			f.editField(ctx, b, mes, field)
+		}
+	}
+}
+
+// submit validates the form and passes the values to the done handlers, the form stays open on an error or a conflict
+func (f *EditForm) submit(ctx context.Context) {
+	fmt.Println("[EditForm.submit] done, changed fields:", len(f.changedFields()))
+	if errs := f.validate(); len(errs) > 0 {
+		fmt.Println("[EditForm.submit] invalid fields:", len(errs))
+		f.submitted = true
+		f.Show(ctx)
+		return
+	}
+
+	err := f.done()
+	var conflictErr *ConflictError
+	if errors.As(err, &conflictErr) {
+		fmt.Println("[EditForm.submit] conflict:", err)
+		if f.conflict, err = f.newConflict(conflictErr); err == nil {
+			f.Show(ctx)
+			return
+		}
+	}
+	if err != nil {
+		// the form stays open with the edits, so the user can retry
+		fmt.Println("[EditForm.submit] done:", err)
+		f.doneErr = err
+		f.Show(ctx)
+		return
+	}
+	f.unregister()
+	f.finish(ctx, true)
+}
+
+// editField opens the editor of the field, or the page of a nested struct or slice
+func (f *EditForm) editField(ctx context.Context, b *bot.Bot, mes models.MaybeInaccessibleMessage, field field) {
+	switch field.kind {
+	case kindStruct, kindList, kindMultiSelect:
+		f.openPath(field.path)
+		f.Show(ctx)
+	case kindBool:
+		if err := f.setValue(field.path, field.toggle(f.value(field.path))); err != nil {
+			fmt.Println("[EditForm.editField]", err)
+		}
+		f.Show(ctx)
+	case kindTime:
+		f.showDatePicker(ctx, b, mes, field)
+	default:
+		f.showQuestion(ctx, b, mes, field)
+	}
+}
 
-			q := questionaire.NewBuilder(mes.Message.Chat.ID, f.manager).
-				SetOnDoneHandler(func(ctx context.Context, b *bot.Bot, chatID any, req map[string]interface{}) error {
+// addItem asks for a new item of the open list, a nested struct or slice item is added at once and opened
+func (f *EditForm) addItem(ctx context.Context, b *bot.Bot, mes models.MaybeInaccessibleMessage) {
+	open, ok := f.openField()
+	if !ok {
+		return
+	}
+
+	item := itemField(open, f.items(open).Len())
+	if item.isContainer() {
+		if err := f.setValue(item.path, reflect.Zero(item.Type).Interface()); err != nil {
+			fmt.Println("[EditForm.addItem]", err)
+			return
+		}
+	}
+	f.editField(ctx, b, mes, item)
+}
 
-					// var req map[string]interface{}
-					// if err := json.Unmarshal(answersByte, &req); err != nil {
-					// 	return err
-					// }
+// done passes the edited values to the done handlers
+func (f *EditForm) done() error {
+	if f.OnDoneEditHandler != nil {
+		if err := f.OnDoneEditHandler(f.data, f.patch()); err != nil {
+			return err
+		}
+	}
+	if f.onDoneTypedHandler == nil {
+		return nil
+	}
 
-					fmt.Println("[EditForm.editCallback] received answers:", req)
+	result, err := f.typedResult()
+	if err != nil {
+		return err
+	}
+	return f.onDoneTypedHandler(result)
+}
 
-					answer := req[key]
-					if answer == nil {
-						return fmt.Errorf("no answer for key: %s", key)
-					}
+// typedResult returns a pointer to a copy of the target struct with the edited values
+func (f *EditForm) typedResult() (any, error) {
+	if !f.target.IsValid() {
+		return nil, fmt.Errorf("target is not a struct")
+	}
 
-					if answer != f.prefix+"cancel_"+key {
-						if f.stringTransformer[key] != nil {
-							var err error
-							req[key], err = f.stringTransformer[key](req[key].(string))
-							if err != nil {
-								return err
-							}
-						}
+	result := reflect.New(f.target.Type())
+	result.Elem().Set(f.target)
 
-						f.data[key] = req[key]
+	for _, field := range f.fields {
+		value := reflect.ValueOf(f.data[field.Key])
+		if !value.IsValid() {
+			value = reflect.Zero(field.Type)
+		}
+		if !value.Type().AssignableTo(field.Type) {
+			return nil, fmt.Errorf("value of %s is a %s, not a %s", field.Key, value.Type(), field.Type)
+		}
+		result.Elem().FieldByIndex(field.Index).Set(value)
+	}
 
-					}
+	return result.Interface(), nil
+}
 
-					f.Show(ctx)
+// field returns the field with the key
+func (f *EditForm) field(key string) (field, bool) {
+	for _, field := range f.fields {
+		if field.Key == key {
+			return field, true
+		}
+	}
+	return field{}, false
+}
 
-					return nil
-				})
+// showQuestion asks for a new value of a text, number or enum field
+func (f *EditForm) showQuestion(ctx context.Context, b *bot.Bot, mes models.MaybeInaccessibleMessage, field field) {
+	key := field.path
+	label := field.DisplayLabel()
+	cancelAnswer := f.prefix + "cancel_" + key
+	choices := f.fieldChoices(field)
+
+	q := questionaire.NewBuilder(mes.Message.Chat.ID, f.manager).
+		SetRouter(f.router).
+		SetSigningKey(f.signingKey).
+		SetAllowEditAnswers(false).
+		SetDeleteAnswers(true).
+		SetOnDoneHandler(func(ctx context.Context, b *bot.Bot, chatID any, req map[string]interface{}) error {
+
+			fmt.Println("[EditForm.showQuestion] received answer for:", key)
+
+			answer, ok := req[key].(string)
+			if !ok {
+				return fmt.Errorf("no answer for key: %s", key)
+			}
 
-			if f.choices[key] != nil {
-				q.AddQuestion(
-					key,
-					"Select value for "+key+" or enter new value: ",
-					f.choices[key],
-					nil,
-				)
-			} else {
-				q.AddQuestion(key, "Enter new value for: "+key, nil, nil)
+			if answer != cancelAnswer {
+				value, err := f.parseAnswer(field, answer)
+				if err != nil {
+					return err
+				}
+				if err := f.setValue(key, value); err != nil {
+					return err
+				}
 			}
 
-			q.Show(ctx, b, mes.Message.Chat.ID)
+			f.Show(ctx)
+
+			return nil
+		})
+
+	// parse errors are shown with the question, so the user can answer again
+	validate := func(answer string) error {
+		if answer == cancelAnswer {
+			return nil
+		}
+		_, err := f.parseAnswer(field, answer)
+		return err
+	}
+
+	text := "Enter new value for: " + label
+	if field.kind == kindNumber {
+		text = "Enter a number for: " + label
+	}
+	if choices != nil {
+		text = "Select value for " + label + " or enter new value: "
+	}
+	if field.Help != "" {
+		text += "\n" + field.Help
+	}
+	if field.Secret {
+		// the answer is masked and the message of the user deleted at once
+		q.AddSecretQuestion(key, text, choices, validate)
+	} else {
+		q.AddQuestion(key, text, choices, validate)
+	}
+
+	f.showPrompt(ctx, field)
+	q.Show(ctx, b, mes.Message.Chat.ID)
+}
+
+// parseAnswer transforms the answer with the transformer of the path, parses it into the field type
+// and checks it with the validator of the path
+func (f *EditForm) parseAnswer(field field, answer string) (any, error) {
+	if f.stringTransformer[field.path] != nil {
+		var err error
+		answer, err = f.stringTransformer[field.path](answer)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	value, err := field.parse(answer)
+	if err != nil {
+		return nil, err
+	}
+	if validate := f.validators[field.path]; validate != nil {
+		if err := validate(value); err != nil {
+			return nil, err
+		}
+	}
+	return value, nil
+}
+
+// showDatePicker asks for a new date of a time.Time field.
+// The picker is kept in the form message, so its handler is removed on select and cancel.
+func (f *EditForm) showDatePicker(ctx context.Context, b *bot.Bot, mes models.MaybeInaccessibleMessage, field field) {
+	var picker *datepicker.DatePicker
+	opts := []datepicker.Option{
+		datepicker.WithRouter(f.router),
+		datepicker.WithSigningKey(f.signingKey),
+		datepicker.NoDeleteAfterSelect(),
+		datepicker.NoDeleteAfterCancel(),
+		datepicker.OnCancel(func(ctx context.Context, b *bot.Bot, mes models.MaybeInaccessibleMessage) {
+			picker.Unregister(b)
+			f.Show(ctx)
+		}),
+	}
+	if current, ok := dereference(f.value(field.path)).(time.Time); ok && !current.IsZero() {
+		opts = append(opts, datepicker.CurrentDate(current))
+	}
 
+	picker = datepicker.New(b, func(ctx context.Context, b *bot.Bot, mes models.MaybeInaccessibleMessage, date time.Time) {
+		picker.Unregister(b)
+		if err := f.setValue(field.path, field.withDate(f.value(field.path), date)); err != nil {
+			fmt.Println("[EditForm.showDatePicker]", err)
 		}
+		f.Show(ctx)
+	}, opts...)
+
+	// the picker replaces the form in its message, Show brings the form back
+	if _, err := f.render(ctx, "Select a date for: "+field.DisplayLabel(), picker); err != nil {
+		fmt.Println("[EditForm.showDatePicker]", err)
 	}
 }
 
@@ -284,20 +680,110 @@ func (f *EditForm) Show(ctx context.Context) (*models.Message, error) {
 	if f.chatID == nil {
 		return nil, fmt.Errorf("chatID is not set")
 	}
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	// one handler serves every Show of the form, the buttons carry the form prefix
+	if f.callbackHandlerID == "" && f.router != nil {
+		f.callbackHandlerID, f.routePrefix = f.router.Handle("ef", f.callback)
+	} else if f.callbackHandlerID == "" {
+		f.callbackHandlerID = f.botInstance.RegisterHandler(bot.HandlerTypeCallbackQueryData, f.prefix, bot.MatchTypePrefix, f.callback)
+	}
 
 	f.rebuildControls()
 
-	filterNode := inline.New(f.botInstance, inline.WithPrefix(f.prefix))
+	markup := make([][]models.InlineKeyboardButton, 0, len(f.buttons))
+	for _, row := range f.buttons {
+		markupRow := make([]models.InlineKeyboardButton, 0, len(row))
+		for _, btn := range row {
+			data, err := f.codec.Encode(f.callbackPrefix(), strings.TrimPrefix(btn.CallbackData, f.callbackPrefix()))
+			if err != nil {
+				return nil, fmt.Errorf("button %q: %w", btn.Text, err)
+			}
+			markupRow = append(markupRow, models.InlineKeyboardButton{Text: btn.Text, CallbackData: data})
+		}
+		markup = append(markup, markupRow)
+	}
+	text := f.formText()
+	f.doneErr = nil
+	return f.render(ctx, text, models.InlineKeyboardMarkup{InlineKeyboard: markup})
+}
+
+// formText returns the conflict, the review, or the form text followed by the open group and the values of the read only fields
+func (f *EditForm) formText() string {
+	if f.conflict != nil {
+		return f.conflictText()
+	}
+	if f.reviewing {
+		return f.reviewText() + f.errorText()
+	}
+
+	text := f.text
+	if len(f.path) > 0 {
+		return text + "\n" + fmt.Sprintf(TEXT_FORMAT_GROUP, f.breadcrumbs()) + f.errorText()
+	}
+	if f.group != "" {
+		text += "\n" + fmt.Sprintf(TEXT_FORMAT_GROUP, f.group)
+	}
+	for _, field := range f.fields {
+		if field.ReadOnly && !field.NoEdit {
+			text += "\n" + fmt.Sprintf(TEXT_FORMAT, field.DisplayLabel(), field.displayValue(f.data[field.Key]))
+		}
+	}
+	return text + f.errorText()
+}
 
-	for _, b := range f.buttons {
-		filterNode.Row()
-		for _, btn := range b {
-			filterNode.Button(btn.Text, []byte(btn.CallbackData), btn.OnClick)
+// callback receives every button click of the form.
+// The form message is edited by every Show, other messages of the form are stale and deleted.
+func (f *EditForm) callback(ctx context.Context, b *bot.Bot, update *models.Update) {
+	command, err := f.codec.Decode(f.callbackPrefix(), update.CallbackQuery.Data)
+	if err != nil {
+		fmt.Println("[EditForm.callback]", err)
+		f.callbackAnswer(ctx, b, update.CallbackQuery, callbackdata.InvalidButtonText)
+		return
+	}
+	f.callbackAnswer(ctx, b, update.CallbackQuery, "")
+
+	mes := update.CallbackQuery.Message
+	if mes.Message != nil && mes.Message.ID != f.msgID {
+		if _, err := b.DeleteMessage(ctx, &bot.DeleteMessageParams{
+			ChatID:    mes.Message.Chat.ID,
+			MessageID: mes.Message.ID,
+		}); err != nil {
+			fmt.Println("[EditForm.callback] delete message:", err)
 		}
 	}
-	return f.botInstance.SendMessage(ctx, &bot.SendMessageParams{
-		ChatID:      f.chatID,
-		Text:        f.text,
-		ReplyMarkup: filterNode,
+	f.editCallback(ctx, b, mes, []byte(f.callbackPrefix()+command))
+}
+
+// callbackAnswer answers the callback query, through the router for a routed form.
+func (f *EditForm) callbackAnswer(ctx context.Context, b *bot.Bot, callbackQuery *models.CallbackQuery, text string) {
+	if router.Answer(ctx, text) {
+		return
+	}
+	b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+		CallbackQueryID: callbackQuery.ID,
+		Text:            text,
 	})
 }
+
+// unregister removes the form's callback handler from the router or the bot.
+func (f *EditForm) unregister() {
+	if f.callbackHandlerID != "" && f.router != nil {
+		f.router.Remove(f.callbackHandlerID)
+	} else if f.callbackHandlerID != "" {
+		f.botInstance.UnregisterHandler(f.callbackHandlerID)
+	}
+	f.callbackHandlerID = ""
+	f.routePrefix = ""
+}
+
+// callbackPrefix is the prefix of the form's buttons.
+// It is the form prefix, or the prefix assigned by the router for a routed form.
+func (f *EditForm) callbackPrefix() string {
+	if f.routePrefix != "" {
+		return f.routePrefix
+	}
+	return f.prefix
+}
@@ -0,0 +1,580 @@
+package editform
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/jkevinp/tgui/internal/testbot"
+	"github.com/jkevinp/tgui/parser"
+	"github.com/jkevinp/tgui/questionaire"
+	"github.com/jkevinp/tgui/router"
+	"github.com/stretchr/testify/assert"
+)
+
+type status string
+
+func (status) EnumValues() []string { return []string{"active", "blocked"} }
+
+type user struct {
+	Name     string    `json:"name"`
+	Age      int       `json:"age"`
+	Score    *float64  `json:"score"`
+	Admin    bool      `json:"admin"`
+	Birthday time.Time `json:"birthday"`
+	Status   status    `json:"status"`
+	Tags     []string  `json:"tags"`
+	Secret   string    `json:"-"`
+	internal int
+}
+
+func TestFieldKinds(t *testing.T) {
+	f := New(nil, "Edit user", user{Name: "Ann", Age: 30}, nil, nil, int64(1), nil)
+
+	var keys []string
+	kinds := map[string]fieldKind{}
+	for _, field := range f.fields {
+		keys = append(keys, field.Key)
+		kinds[field.Key] = field.kind
+	}
+	assert.Equal(t, []string{"name", "age", "score", "admin", "birthday", "status", "tags"}, keys, "declaration order")
+	assert.Equal(t, kindText, kinds["name"])
+	assert.Equal(t, kindNumber, kinds["age"])
+	assert.Equal(t, kindNumber, kinds["score"])
+	assert.Equal(t, kindBool, kinds["admin"])
+	assert.Equal(t, kindTime, kinds["birthday"])
+	assert.Equal(t, kindEnum, kinds["status"])
+	assert.Equal(t, kindMultiSelect, kinds["tags"])
+
+	assert.Equal(t, 30, f.data["age"], "values keep the field type")
+}
+
+func TestTypedResult(t *testing.T) {
+	target := user{Name: "Ann", Age: 30, Birthday: time.Date(1990, 5, 1, 8, 30, 0, 0, time.UTC), Tags: []string{"a"}, internal: 7}
+	f := New(nil, "Edit user", &target, nil, nil, int64(1), nil)
+
+	fields := map[string]field{}
+	for _, field := range f.fields {
+		fields[field.Key] = field
+	}
+
+	age, err := f.parseAnswer(fields["age"], "31")
+	assert.NoError(t, err)
+	f.data["age"] = age
+	_, err = f.parseAnswer(fields["age"], "thirty")
+	assert.Error(t, err)
+
+	score, err := f.parseAnswer(fields["score"], "4,5")
+	assert.NoError(t, err)
+	f.data["score"] = score
+
+	_, err = f.parseAnswer(fields["status"], "deleted")
+	assert.Error(t, err)
+	active, err := f.parseAnswer(fields["status"], "active")
+	assert.NoError(t, err)
+	f.data["status"] = active
+
+	f.data["admin"] = fields["admin"].toggle(f.data["admin"])
+	f.data["birthday"] = fields["birthday"].withDate(f.data["birthday"], time.Date(1991, 6, 2, 0, 0, 0, 0, time.Local))
+
+	var got *user
+	f.SetOnDoneTypedHandler(func(result any) error {
+		got = result.(*user)
+		return nil
+	})
+	assert.NoError(t, f.done())
+
+	assert.Equal(t, 31, got.Age)
+	assert.Equal(t, 4.5, *got.Score)
+	assert.True(t, got.Admin)
+	assert.Equal(t, status("active"), got.Status)
+	assert.Equal(t, time.Date(1991, 6, 2, 8, 30, 0, 0, time.UTC), got.Birthday, "the clock time is kept")
+	assert.Equal(t, []string{"a"}, got.Tags)
+	assert.Equal(t, 7, got.internal, "unexported fields are copied")
+	assert.Equal(t, 30, target.Age, "the target is not modified")
+}
+
+func TestFieldTags(t *testing.T) {
+	type account struct {
+		ID       int    `json:"id" tg:"readonly;order:-1"`
+		Name     string `json:"name" tg:"label:Full name;required;max:5"`
+		Password string `json:"password" tg:"secret"`
+		Role     string `json:"role" tg:"choices:admin|viewer"`
+	}
+	f := New(nil, "Edit account", account{ID: 7, Name: "Ann", Password: "hunter2", Role: "admin"}, nil, nil, int64(1), nil)
+	assert.NoError(t, f.err)
+
+	f.rebuildControls()
+	var texts []string
+	for _, row := range f.buttons[:len(f.buttons)-1] {
+		texts = append(texts, row[0].Text)
+	}
+	assert.Equal(t, []string{"Full name: Ann", "password: " + secretMask, "role: admin"}, texts)
+	assert.Equal(t, "Edit account\nid: 7", f.formText(), "read only fields are in the form text")
+
+	fields := map[string]field{}
+	for _, field := range f.fields {
+		fields[field.Key] = field
+	}
+	_, err := f.parseAnswer(fields["name"], "Annabel")
+	assert.Error(t, err)
+	_, err = f.parseAnswer(fields["role"], "owner")
+	assert.Error(t, err)
+	assert.Equal(t, kindEnum, fields["role"].kind)
+
+	type broken struct {
+		Start string `tg:"format:15:04"`
+	}
+	f = New(nil, "Edit", broken{}, nil, nil, int64(1), nil)
+	assert.Error(t, f.err)
+}
+
+func TestGroupsAndPages(t *testing.T) {
+	type settings struct {
+		Name    string `json:"name"`
+		Street  string `json:"street" tg:"group:Address"`
+		City    string `json:"city" tg:"group:Address"`
+		Email   string `json:"email"`
+		Phone   string `json:"phone" tg:"group:Contact"`
+		Country string `json:"country" tg:"group:Address;order:1"`
+	}
+	f := New(nil, "Settings", settings{}, nil, nil, int64(1), nil).SetPageSize(2)
+
+	texts := func() []string {
+		f.rebuildControls()
+		var texts []string
+		for _, row := range f.buttons {
+			for _, btn := range row {
+				texts = append(texts, btn.Text)
+			}
+		}
+		return texts
+	}
+
+	assert.Equal(t, []string{"name: ", "📂 Address ›", "‹", "1/2", "›", "✅ Done", "❌ Cancel"}, texts())
+	assert.True(t, f.navigate("page_1"))
+	assert.Equal(t, []string{"email: ", "📂 Contact ›", "‹", "2/2", "›", "✅ Done", "❌ Cancel"}, texts())
+
+	f.navigate("page_0")
+	f.navigate("group_Address")
+	assert.Equal(t, []string{"street: ", "city: ", "‹", "1/2", "›", "‹ Back", "✅ Done", "❌ Cancel"}, texts())
+	assert.Equal(t, "Settings\n📂 Address ›", f.formText())
+	f.navigate("page_1")
+	f.data["country"] = "NL"
+	assert.Equal(t, []string{"🆕 country: NL", "‹", "2/2", "›", "‹ Back", "🔍 Review changes (1)", "✅ Done", "❌ Cancel"}, texts(), "ordered last")
+
+	f.navigate("back")
+	assert.Equal(t, 0, f.page, "back returns to the page of the group")
+	assert.Equal(t, "🆕 📂 Address ›", texts()[1])
+	assert.False(t, f.navigate("edit_name"))
+
+	f.SetPageSize(10)
+	assert.Equal(t, []string{"name: ", "🆕 📂 Address ›", "email: ", "📂 Contact ›", "🔍 Review changes (1)", "✅ Done", "❌ Cancel"}, texts(), "a single page has no navigation")
+}
+
+func TestReview(t *testing.T) {
+	type account struct {
+		Name     string `json:"name" tg:"label:Full name"`
+		Age      int    `json:"age"`
+		Password string `json:"password" tg:"secret"`
+	}
+	var data, patch map[string]interface{}
+	f := New(nil, "Edit account", account{Name: "Ann", Age: 30, Password: "a"}, func(d, p map[string]interface{}) error {
+		data, patch = d, p
+		return nil
+	}, nil, int64(1), nil)
+
+	f.data["name"] = "Bob"
+	f.data["age"] = 31
+	f.data["password"] = "b"
+
+	assert.True(t, f.review("review"))
+	f.rebuildControls()
+	var texts []string
+	for _, row := range f.buttons {
+		for _, btn := range row {
+			texts = append(texts, btn.Text)
+		}
+	}
+	assert.Equal(t, []string{"↩️ Revert Full name", "↩️ Revert age", "↩️ Revert password", "♻️ Reset all", "‹ Back", "✅ Done", "❌ Cancel"}, texts)
+	assert.Equal(t, "Edit account\nReview changes:\nFull name: Ann → Bob\nage: 30 → 31\npassword: "+secretMask+" → "+secretMask, f.formText())
+
+	f.review("revert_age")
+	assert.Equal(t, 30, f.data["age"])
+	assert.NoError(t, f.done())
+	assert.Equal(t, map[string]interface{}{"name": "Bob", "password": "b"}, patch)
+	assert.Equal(t, 30, data["age"], "the full data")
+
+	f.review("reset")
+	assert.False(t, f.reviewing)
+	assert.Empty(t, f.patch())
+
+	f.review("review")
+	f.rebuildControls()
+	assert.False(t, f.reviewing, "nothing to review")
+}
+
+func TestNested(t *testing.T) {
+	type geo struct {
+		Lat float64 `json:"lat"`
+	}
+	type address struct {
+		City string `json:"city" tg:"label:City;required"`
+		Geo  *geo   `json:"geo"`
+	}
+	type phone struct {
+		Number string `json:"number"`
+	}
+	type person struct {
+		Name    string   `json:"name"`
+		Address address  `json:"address" tg:"label:Address"`
+		Phones  []phone  `json:"phones" tg:"label:Phones"`
+		Scores  []int    `json:"scores"`
+		Tags    []string `json:"tags"`
+		Roles   []status `json:"roles"`
+	}
+	target := person{Name: "Ann", Address: address{City: "Oslo"}, Scores: []int{1, 2, 3}, Tags: []string{"a", "b"}}
+	f := New(nil, "Edit person", &target, nil, nil, int64(1), nil)
+	assert.NoError(t, f.err)
+
+	kinds := map[string]fieldKind{}
+	for _, field := range f.fields {
+		kinds[field.Key] = field.kind
+	}
+	assert.Equal(t, kindStruct, kinds["address"])
+	assert.Equal(t, kindList, kinds["phones"])
+	assert.Equal(t, kindList, kinds["scores"])
+	assert.Equal(t, kindMultiSelect, kinds["tags"])
+	assert.Equal(t, kindMultiSelect, kinds["roles"])
+
+	texts := func() []string {
+		f.rebuildControls()
+		var texts []string
+		for _, row := range f.buttons {
+			for _, btn := range row {
+				texts = append(texts, btn.Text)
+			}
+		}
+		return texts
+	}
+
+	// nested struct
+	handled, err := f.nested("open_address")
+	assert.True(t, handled)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"City: Oslo", "geo ›", "‹ Back", "✅ Done", "❌ Cancel"}, texts())
+	city, ok := f.fieldAt("address.city")
+	assert.True(t, ok)
+	_, err = f.parseAnswer(city, " ")
+	assert.Error(t, err, "tags of nested fields are validated")
+	assert.NoError(t, f.setValue("address.city", "Bergen"))
+	assert.NoError(t, f.setValue("address.geo.lat", 59.9))
+	f.nested("open_address.geo")
+	assert.Equal(t, "Edit person\n📂 Address › geo ›", f.formText(), "breadcrumbs")
+	f.nested("back")
+	f.nested("back")
+	assert.Empty(t, f.path)
+	assert.Equal(t, "🆕 Address ›", texts()[1])
+
+	// a stale button of a deeper page after Back
+	f.nested("open_address")
+	f.nested("back")
+	f.nested("open_address.geo")
+	assert.NotPanics(t, func() {
+		f.nested("back")
+		f.nested("back")
+		f.nested("back")
+	})
+	assert.Empty(t, f.path)
+	assert.Empty(t, f.trail)
+
+	// list of structs
+	f.nested("open_phones")
+	assert.NoError(t, f.setValue("phones.0", phone{Number: "1"}))
+	assert.NoError(t, f.setValue("phones.1.number", "2"))
+	assert.Equal(t, []string{"1. {1} ›", "⬆️", "⬇️", "🗑", "2. {2} ›", "⬆️", "⬇️", "🗑", "➕ Add", "‹ Back", "🔍 Review changes (2)", "✅ Done", "❌ Cancel"}, texts())
+	f.nested("up_1")
+	f.nested("back")
+
+	// list of numbers
+	f.nested("open_scores")
+	f.nested("down_0")
+	f.nested("remove_2")
+	f.nested("back")
+
+	// tags and choices
+	f.nested("open_tags")
+	assert.NoError(t, f.setValue("tags.2", "c"))
+	f.nested("toggle_0")
+	assert.Equal(t, []string{"⬜ a", "✅ b", "✅ c", "➕ Add", "‹ Back", "🔍 Review changes (4)", "✅ Done", "❌ Cancel"}, texts())
+	f.nested("back")
+	f.nested("open_roles")
+	f.nested("toggle_1")
+	assert.Equal(t, []string{"⬜ active", "✅ blocked", "‹ Back", "🔍 Review changes (5)", "✅ Done", "❌ Cancel"}, texts(), "choices can't be added")
+	f.nested("back")
+
+	result, err := f.typedResult()
+	assert.NoError(t, err)
+	got := result.(*person)
+	assert.Equal(t, address{City: "Bergen", Geo: &geo{Lat: 59.9}}, got.Address)
+	assert.Equal(t, []phone{{Number: "2"}, {Number: "1"}}, got.Phones)
+	assert.Equal(t, []int{2, 1}, got.Scores)
+	assert.Equal(t, []string{"b", "c"}, got.Tags)
+	assert.Equal(t, []status{"blocked"}, got.Roles)
+
+	assert.Equal(t, person{Name: "Ann", Address: address{City: "Oslo"}, Scores: []int{1, 2, 3}, Tags: []string{"a", "b"}}, target, "the target is not modified")
+	assert.Equal(t, []int{1, 2, 3}, f.initialData["scores"], "the initial values are not modified")
+}
+
+func TestValidation(t *testing.T) {
+	type item struct {
+		SKU string `json:"sku" tg:"required"`
+	}
+	type order struct {
+		Name  string `json:"name" tg:"required"`
+		Qty   int    `json:"qty" tg:"min:1"`
+		Items []item `json:"items" tg:"min:1"`
+		Note  string `json:"note" tg:"group:Extra;max:3"`
+	}
+	f := New(nil, "Order", order{Qty: 1}, nil, nil, int64(1), nil).
+		SetValidator("qty", func(value any) error {
+			if value.(int)%2 != 0 {
+				return fmt.Errorf("must be even")
+			}
+			return nil
+		})
+
+	fields := map[string]field{}
+	for _, field := range f.fields {
+		fields[field.Key] = field
+	}
+	_, err := f.parseAnswer(fields["qty"], "3")
+	assert.EqualError(t, err, "must be even", "validators run on every edit")
+	_, err = f.parseAnswer(fields["qty"], "0")
+	assert.Error(t, err, "tags run before validators")
+
+	texts := func() []string {
+		f.rebuildControls()
+		var texts []string
+		for _, row := range f.buttons {
+			for _, btn := range row {
+				texts = append(texts, btn.Text)
+			}
+		}
+		return texts
+	}
+	assert.Equal(t, []string{"name: ", "qty: 1", "items (0) ›", "📂 Extra ›", "✅ Done", "❌ Cancel"}, texts(), "unchanged fields are not highlighted before Done")
+
+	f.data["note"] = "long"
+	assert.Equal(t, "⚠️ 🆕 📂 Extra ›", texts()[3], "changed fields are highlighted")
+
+	assert.Len(t, f.validate(), 4)
+	f.submitted = true
+	assert.Equal(t, []string{"⚠️ name: ", "⚠️ qty: 1", "⚠️ items (0) ›", "⚠️ 🆕 📂 Extra ›", "🔍 Review changes (1)", "✅ Done", "❌ Cancel"}, texts())
+	assert.Equal(t, "Order\nPlease correct the fields marked ⚠️\n⚠️ name is required\n⚠️ qty: must be even\n⚠️ items must have at least 1 items\n⚠️ note must have a length of at most 3", f.formText())
+
+	f.data["name"] = "Ann"
+	f.data["qty"] = 2
+	f.data["note"] = ""
+	assert.NoError(t, f.setValue("items.0", item{}))
+	assert.Equal(t, []fieldError{{"items.0.sku", fmt.Errorf("sku is required")}}, f.validate(), "nested fields are validated")
+	texts()
+	assert.True(t, f.hasError("items"))
+
+	assert.NoError(t, f.setValue("items.0.sku", "A1"))
+	assert.Empty(t, f.validate())
+}
+
+func TestSingleMessage(t *testing.T) {
+	b, server := testbot.New(t)
+	var err error
+
+	type account struct {
+		Name string `json:"name"`
+	}
+	failing := true
+	f := New(b, "Edit account", account{Name: "Ann"}, func(data, patch map[string]interface{}) error {
+		if failing {
+			return fmt.Errorf("try again")
+		}
+		return nil
+	}, nil, int64(42), questionaire.NewManager())
+	ctx := context.Background()
+	mes := models.MaybeInaccessibleMessage{Message: &models.Message{ID: 1, Chat: models.Chat{ID: 42}}}
+
+	_, err = f.Show(ctx)
+	assert.NoError(t, err)
+	_, err = f.Show(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"sendMessage", "editMessageText"}, server.Methods(), "the form message is edited")
+
+	server.Reset()
+	f.editCallback(ctx, b, mes, []byte(f.callbackPrefix()+"edit_name"))
+	assert.Equal(t, []string{"editMessageText", "sendMessage"}, server.Methods(), "the form is collapsed while the question is asked")
+	assert.Contains(t, server.Requests()[0].Body, "Editing name")
+
+	server.Reset()
+	f.data["name"] = "Bob"
+	f.editCallback(ctx, b, mes, []byte(f.callbackPrefix()+"done"))
+	assert.Equal(t, []string{"editMessageText"}, server.Methods())
+	assert.Contains(t, server.Last(), "try again", "the form stays open with the error")
+	assert.NotEmpty(t, f.callbackHandlerID)
+
+	server.Reset()
+	failing = false
+	f.editCallback(ctx, b, mes, []byte(f.callbackPrefix()+"done"))
+	assert.Equal(t, []string{"editMessageText"}, server.Methods())
+	assert.Contains(t, server.Last(), "Saved\nname: Bob", "collapsed into a summary")
+	assert.Empty(t, f.callbackHandlerID)
+
+	server.Reset()
+	f.SetFinishMode(FinishDelete)
+	f.Show(ctx)
+	f.editCallback(ctx, b, mes, []byte(f.callbackPrefix()+"cancel"))
+	assert.Equal(t, []string{"sendMessage", "deleteMessage"}, server.Methods())
+}
+
+func TestConflict(t *testing.T) {
+	b, server := testbot.New(t)
+	ctx := context.Background()
+	mes := models.MaybeInaccessibleMessage{Message: &models.Message{ID: 1, Chat: models.Chat{ID: 42}}}
+
+	type record struct {
+		Name  string `json:"name"`
+		Email string `json:"email"`
+		Age   int    `json:"age"`
+	}
+	saved := record{Name: "Ann", Email: "b@example.com", Age: 31}
+
+	newForm := func(result *map[string]interface{}) *EditForm {
+		var f *EditForm
+		f = New(b, "Edit record", record{Name: "Ann", Email: "a@example.com", Age: 30}, func(data, patch map[string]interface{}) error {
+			if f.Version() != "2" {
+				return fmt.Errorf("save: %w", &ConflictError{Version: "2", Current: &saved})
+			}
+			*result = data
+			return nil
+		}, nil, int64(42), questionaire.NewManager())
+		f.SetVersion("1")
+		f.data["name"] = "Bob"
+		f.data["email"] = "c@example.com"
+		return f
+	}
+
+	var result map[string]interface{}
+	f := newForm(&result)
+	f.editCallback(ctx, b, mes, []byte(f.callbackPrefix()+"done"))
+	assert.Nil(t, result)
+	assert.NotNil(t, f.conflict)
+	assert.Contains(t, server.Last(), "email: yours c@example.com, theirs b@example.com")
+	assert.NotContains(t, server.Last(), "name: yours", "only changed by the form")
+
+	f.editCallback(ctx, b, mes, []byte(f.callbackPrefix()+"reload"))
+	assert.Nil(t, f.conflict)
+	assert.Equal(t, "2", f.Version())
+	assert.Equal(t, map[string]interface{}{"name": "Ann", "email": "b@example.com", "age": 31}, f.initialData)
+	assert.Equal(t, map[string]interface{}{"name": "Bob", "email": "c@example.com"}, f.patch(), "the changes are reapplied")
+	f.editCallback(ctx, b, mes, []byte(f.callbackPrefix()+"done"))
+	assert.Equal(t, map[string]interface{}{"name": "Bob", "email": "c@example.com", "age": 31}, result)
+
+	result = nil
+	f = newForm(&result)
+	f.editCallback(ctx, b, mes, []byte(f.callbackPrefix()+"done"))
+	f.editCallback(ctx, b, mes, []byte(f.callbackPrefix()+"overwrite"))
+	assert.Equal(t, "2", f.Version())
+	assert.Equal(t, map[string]interface{}{"name": "Bob", "email": "c@example.com", "age": 30}, result)
+}
+
+func TestSecretField(t *testing.T) {
+	b, server := testbot.New(t)
+	ctx := context.Background()
+	mes := models.MaybeInaccessibleMessage{Message: &models.Message{ID: 1, Chat: models.Chat{ID: 42}}}
+
+	type account struct {
+		APIKey string `json:"api_key" tg:"label:API key;secret"`
+	}
+	manager := questionaire.NewManager()
+	f := New(b, "Edit account", account{APIKey: "old"}, nil, nil, int64(42), manager)
+	f.Show(ctx)
+	f.editCallback(ctx, b, mes, []byte(f.callbackPrefix()+"edit_api_key"))
+
+	server.Reset()
+	manager.HandleMessage(ctx, b, &models.Update{Message: &models.Message{ID: 7, Chat: models.Chat{ID: 42}, Text: "s3cr3t"}})
+	assert.Equal(t, "deleteMessage", server.Methods()[0], "the answer is deleted at once")
+	assert.Equal(t, "s3cr3t", f.data["api_key"])
+
+	f.editCallback(ctx, b, mes, []byte(f.callbackPrefix()+"done"))
+	assert.Contains(t, server.Last(), "API key: "+secretMask)
+	for _, body := range server.Bodies("") {
+		assert.NotContains(t, body, "s3cr3t")
+	}
+
+	specs, err := parser.ParseFieldSpecs(struct {
+		Region string `json:"region" tg:"choices:eu|us;secret"`
+	}{})
+	assert.NoError(t, err)
+	_, err = newField(specs[0], "").parse("s3cr3t")
+	assert.EqualError(t, err, "region is not one of eu, us", "the error doesn't quote a secret answer")
+}
+
+func TestHandlerCountAcrossShows(t *testing.T) {
+	b, _ := testbot.New(t)
+	r := router.New(b)
+	manager := questionaire.NewManager()
+	ctx := context.Background()
+	mes := models.MaybeInaccessibleMessage{Message: &models.Message{ID: 1, Chat: models.Chat{ID: 42}}}
+
+	type account struct {
+		Name string `json:"name"`
+	}
+	f := New(b, "Edit account", account{Name: "Ann"}, nil, nil, int64(42), manager).SetRouter(r)
+	for i := 0; i < 100; i++ {
+		_, err := f.Show(ctx)
+		assert.NoError(t, err)
+		f.editCallback(ctx, b, mes, []byte(f.callbackPrefix()+"edit_name"))
+		manager.HandleMessage(ctx, b, &models.Update{Message: &models.Message{ID: 2, Chat: models.Chat{ID: 42}, Text: fmt.Sprint("Ann ", i)}})
+	}
+	assert.Equal(t, "Ann 99", f.data["name"])
+	assert.Equal(t, 1, r.Len(), "one handler per form, the questions remove theirs")
+
+	f.editCallback(ctx, b, mes, []byte(f.callbackPrefix()+"done"))
+	assert.Equal(t, 0, r.Len())
+}
+
+func TestDatePickerHandlers(t *testing.T) {
+	b, server := testbot.New(t, bot.WithNotAsyncHandlers())
+	r := router.New(b)
+	ctx := context.Background()
+	mes := models.MaybeInaccessibleMessage{Message: &models.Message{ID: 1, Chat: models.Chat{ID: 42}}}
+
+	type event struct {
+		Date time.Time `json:"date"`
+	}
+	f := New(b, "Edit event", event{Date: time.Date(2024, 5, 1, 0, 0, 0, 0, time.Local)}, nil, nil, int64(42), questionaire.NewManager()).SetRouter(r)
+	_, err := f.Show(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, r.Len())
+
+	// click finds the button of the picker in the last edit and sends its callback to the bot
+	click := func(text string) {
+		match := regexp.MustCompile(`"text":"` + text + `","callback_data":"([^"]+)"`).FindStringSubmatch(server.Last())
+		if !assert.NotNil(t, match, "no %s button", text) {
+			return
+		}
+		b.ProcessUpdate(ctx, &models.Update{CallbackQuery: &models.CallbackQuery{ID: "1", Data: match[1], Message: mes}})
+	}
+
+	f.editCallback(ctx, b, mes, []byte(f.callbackPrefix()+"edit_date"))
+	assert.Equal(t, 2, r.Len())
+	click("15")
+	assert.Equal(t, 15, f.data["date"].(time.Time).Day())
+	assert.Equal(t, 1, r.Len(), "the picker removes its handler on select")
+
+	f.editCallback(ctx, b, mes, []byte(f.callbackPrefix()+"edit_date"))
+	click("Cancel")
+	assert.Equal(t, 1, r.Len(), "the picker removes its handler on cancel")
+}
@@ -0,0 +1,221 @@
+package editform
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jkevinp/tgui/parser"
+)
+
+// Enum is implemented by field types with a fixed set of values, the form offers the values as choices.
+//
+//	type Status string
+//
+//	func (Status) EnumValues() []string { return []string{"active", "blocked"} }
+type Enum = parser.Enum
+
+// fieldKind selects the editor of a field
+type fieldKind int
+
+const (
+	kindText        fieldKind = iota // Text input parsed by parser.ParseValue
+	kindNumber                       // Text input of a number
+	kindBool                         // Toggled by a click
+	kindTime                         // Date from a datepicker
+	kindEnum                         // Choice of the Enum values
+	kindStruct                       // Nested struct edited on a sub-page
+	kindList                         // Slice edited on a list page, items are added, removed and moved
+	kindMultiSelect                  // Slice of choices or strings, toggled on a page of the values
+	kindUnsupported                  // Maps and other types are kept unchanged
+)
+
+// secretMask is shown instead of the value of a field with the secret tag
+const secretMask = "••••••"
+
+var (
+	timeType            = reflect.TypeOf(time.Time{})
+	durationType        = reflect.TypeOf(time.Duration(0))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// pathSeparator joins the keys of the path of a nested field, e.g. "address.city" or "phones.0"
+const pathSeparator = "."
+
+// field is an exported field of the target struct, or of a nested struct or slice, with its tg tags
+type field struct {
+	parser.FieldSpec
+	kind       fieldKind
+	enumValues []string
+	path       string // Keys from the target struct to the field, the key of a top level field
+}
+
+// parseFields returns the fields of a struct in the order of the specs, parent is the path of the struct
+func parseFields(specs []parser.FieldSpec, parent string) []field {
+	fields := make([]field, 0, len(specs))
+
+	for _, spec := range specs {
+		fields = append(fields, newField(spec, parent))
+	}
+
+	return fields
+}
+
+func newField(spec parser.FieldSpec, parent string) field {
+	f := field{FieldSpec: spec, path: spec.Key}
+	if parent != "" {
+		f.path = parent + pathSeparator + spec.Key
+	}
+
+	f.kind, f.enumValues = fieldKindOf(spec.Type)
+	// the choices tag turns a text or number field into a choice of the values
+	if len(spec.Choices) > 0 && (f.kind == kindText || f.kind == kindNumber || f.kind == kindEnum) {
+		f.kind, f.enumValues = kindEnum, spec.Choices
+	}
+	// slices of choices and of strings are edited as tags
+	if f.kind == kindList && (len(spec.Choices) > 0 || indirectType(spec.Type).Elem().Kind() == reflect.String) {
+		f.kind = kindMultiSelect
+	}
+	return f
+}
+
+// itemField returns the field of the item i of a list
+func itemField(list field, i int) field {
+	spec := parser.FieldSpec{
+		Key:    strconv.Itoa(i),
+		Name:   list.Name,
+		Type:   indirectType(list.Type).Elem(),
+		Label:  fmt.Sprintf("%s #%d", list.DisplayLabel(), i+1),
+		Regex:  list.Regex,
+		Format: list.Format,
+		Help:   list.Help,
+		Secret: list.Secret,
+	}
+	return newField(spec, list.path)
+}
+
+// isContainer reports whether the field is edited on a page of its own
+func (f field) isContainer() bool {
+	return f.kind == kindStruct || f.kind == kindList || f.kind == kindMultiSelect
+}
+
+func fieldKindOf(t reflect.Type) (fieldKind, []string) {
+	t = indirectType(t)
+
+	if enum, ok := reflect.New(t).Interface().(Enum); ok {
+		return kindEnum, enum.EnumValues()
+	}
+	if t == timeType {
+		return kindTime, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return kindBool, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		if t == durationType {
+			return kindText, nil
+		}
+		return kindNumber, nil
+	}
+
+	if t.Kind() == reflect.String || reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return kindText, nil
+	}
+
+	switch {
+	case t.Kind() == reflect.Struct:
+		return kindStruct, nil
+	case t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8:
+		return kindList, nil
+	}
+	return kindUnsupported, nil
+}
+
+// indirectType returns the type a pointer type points to
+func indirectType(t reflect.Type) reflect.Type {
+	if t.Kind() == reflect.Ptr {
+		return t.Elem()
+	}
+	return t
+}
+
+// parse validates the answer of the user against the tags and parses it into a value of the field type.
+// The errors of a secret field don't quote the answer.
+func (f field) parse(answer string) (any, error) {
+	if f.kind == kindEnum && !f.isEnumValue(answer) {
+		if f.Secret {
+			return nil, fmt.Errorf("%s is not one of %s", f.DisplayLabel(), strings.Join(f.enumValues, ", "))
+		}
+		return nil, fmt.Errorf("%q is not one of %s", answer, strings.Join(f.enumValues, ", "))
+	}
+	if err := f.Validate(answer); err != nil {
+		return nil, err
+	}
+
+	v, err := f.Parse(answer)
+	if err != nil {
+		return nil, err
+	}
+	return v.Interface(), nil
+}
+
+func (f field) isEnumValue(answer string) bool {
+	for _, value := range f.enumValues {
+		if value == answer {
+			return true
+		}
+	}
+	return false
+}
+
+// toggle returns the flipped value of a bool field
+func (f field) toggle(value any) any {
+	v := reflect.ValueOf(value)
+	if f.Type.Kind() != reflect.Ptr {
+		return !v.Bool()
+	}
+
+	toggled := reflect.New(f.Type.Elem())
+	toggled.Elem().SetBool(v.IsNil() || !v.Elem().Bool())
+	return toggled.Interface()
+}
+
+// withDate returns the date selected in a datepicker, keeping the clock time of the current value
+func (f field) withDate(value any, date time.Time) any {
+	current, _ := dereference(value).(time.Time)
+	if !current.IsZero() {
+		date = time.Date(date.Year(), date.Month(), date.Day(),
+			current.Hour(), current.Minute(), current.Second(), current.Nanosecond(), current.Location())
+	}
+
+	if f.Type.Kind() == reflect.Ptr {
+		return &date
+	}
+	return date
+}
+
+// displayValue formats the value of the field for the form, secret values are masked
+func (f field) displayValue(value any) string {
+	if f.Secret && dereference(value) != nil && !reflect.ValueOf(dereference(value)).IsZero() {
+		return secretMask
+	}
+	return f.FormatValue(value)
+}
+
+// dereference returns the value a pointer points to, or nil for a nil pointer
+func dereference(value any) any {
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Ptr {
+		return value
+	}
+	if v.IsNil() {
+		return nil
+	}
+	return v.Elem().Interface()
+}
@@ -0,0 +1,117 @@
+package editform
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+const (
+	TEXT_EDITING      = "✏️ Editing %s…"
+	TEXT_SAVED        = "✅ Saved"
+	TEXT_CANCELLED    = "❌ Cancelled"
+	TEXT_FORMAT_ITEMS = "%d items"
+)
+
+// FinishMode selects what happens to the form message on Done and Cancel, see SetFinishMode
+type FinishMode int
+
+const (
+	FinishSummary FinishMode = iota // The message is replaced by a read-only summary
+	FinishDelete                    // The message is deleted
+)
+
+// SetFinishMode sets what happens to the form message on Done and Cancel (default: FinishSummary).
+func (f *EditForm) SetFinishMode(mode FinishMode) *EditForm {
+	f.finishMode = mode
+	return f
+}
+
+// render edits the form message, or sends it if there is none yet or it can't be edited
+func (f *EditForm) render(ctx context.Context, text string, markup models.ReplyMarkup) (*models.Message, error) {
+	if f.msgID != 0 {
+		m, err := f.botInstance.EditMessageText(ctx, &bot.EditMessageTextParams{
+			ChatID:      f.chatID,
+			MessageID:   f.msgID,
+			Text:        text,
+			ReplyMarkup: markup,
+		})
+		if err == nil || strings.Contains(err.Error(), "message is not modified") {
+			return m, nil
+		}
+		fmt.Println("[EditForm.render] edit message:", err)
+	}
+
+	m, err := f.botInstance.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:      f.chatID,
+		Text:        text,
+		ReplyMarkup: markup,
+	})
+	if err != nil {
+		return nil, err
+	}
+	f.msgID = m.ID
+	return m, nil
+}
+
+// showPrompt collapses the form while a value is asked in a message of its own,
+// its buttons are removed so no other field is edited meanwhile
+func (f *EditForm) showPrompt(ctx context.Context, field field) {
+	if _, err := f.render(ctx, f.text+"\n"+fmt.Sprintf(TEXT_EDITING, field.DisplayLabel()), nil); err != nil {
+		fmt.Println("[EditForm.showPrompt]", err)
+	}
+}
+
+// finish replaces the form message by a read-only summary, or deletes it
+func (f *EditForm) finish(ctx context.Context, saved bool) {
+	if f.msgID == 0 {
+		return
+	}
+	defer func() { f.msgID = 0 }()
+
+	if f.finishMode == FinishDelete {
+		if _, err := f.botInstance.DeleteMessage(ctx, &bot.DeleteMessageParams{
+			ChatID:    f.chatID,
+			MessageID: f.msgID,
+		}); err != nil {
+			fmt.Println("[EditForm.finish] delete message:", err)
+		}
+		return
+	}
+
+	if _, err := f.botInstance.EditMessageText(ctx, &bot.EditMessageTextParams{
+		ChatID:    f.chatID,
+		MessageID: f.msgID,
+		Text:      f.summaryText(saved),
+	}); err != nil {
+		fmt.Println("[EditForm.finish] edit message:", err)
+	}
+}
+
+// summaryText lists the saved values, secret values masked, or notes the form was cancelled
+func (f *EditForm) summaryText(saved bool) string {
+	if !saved {
+		return f.text + "\n" + TEXT_CANCELLED
+	}
+
+	lines := []string{f.text, TEXT_SAVED}
+	for _, field := range f.fields {
+		if field.NoEdit || field.kind == kindUnsupported {
+			continue
+		}
+		var value string
+		switch field.kind {
+		case kindList:
+			value = fmt.Sprintf(TEXT_FORMAT_ITEMS, f.items(field).Len())
+		case kindMultiSelect:
+			value = strings.Join(f.selected(field), ", ")
+		default:
+			value = f.reviewValue(field, f.data[field.Key])
+		}
+		lines = append(lines, fmt.Sprintf(TEXT_FORMAT, field.DisplayLabel(), value))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,512 @@
+package editform
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/jkevinp/tgui/button"
+	"github.com/jkevinp/tgui/parser"
+)
+
+const (
+	TEXT_FORMAT_NESTED = "%s ›"
+	TEXT_FORMAT_LIST   = "%s (%d) ›"
+	TEXT_FORMAT_MULTI  = "%s: %s ›"
+	TEXT_FORMAT_ITEM   = "%d. %s"
+	TEXT_SELECTED      = "✅ "
+	TEXT_UNSELECTED    = "⬜ "
+	TEXT_BREADCRUMB    = " › "
+)
+
+// location is a page left for a nested page, restored by Back
+type location struct {
+	group string
+	page  int
+}
+
+// structFields returns the fields of a nested struct field
+func (f *EditForm) structFields(parent field) []field {
+	t := indirectType(parent.Type)
+	specs, ok := f.specs[t]
+	if !ok {
+		var err error
+		specs, err = parser.ParseFieldSpecs(reflect.New(t).Interface())
+		if err != nil {
+			fmt.Println("[EditForm.structFields]", parent.path, err)
+		}
+		f.specs[t] = specs
+	}
+	return parseFields(specs, parent.path)
+}
+
+// checkNested parses the tags of the nested structs of the fields, and of their nested structs
+func (f *EditForm) checkNested(fields []field, seen map[reflect.Type]bool) error {
+	for _, fld := range fields {
+		if fld.kind == kindList {
+			fld = itemField(fld, 0)
+		}
+		t := indirectType(fld.Type)
+		if fld.kind != kindStruct || seen[t] {
+			continue
+		}
+		seen[t] = true
+
+		specs, err := parser.ParseFieldSpecs(reflect.New(t).Interface())
+		if err != nil {
+			return fmt.Errorf("field %s: %w", fld.Key, err)
+		}
+		f.specs[t] = specs
+		if err := f.checkNested(parseFields(specs, fld.path), seen); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// child returns the field of a key of a nested struct, or of an index of a slice
+func (f *EditForm) child(parent field, key string) (field, bool) {
+	switch parent.kind {
+	case kindStruct:
+		for _, fld := range f.structFields(parent) {
+			if fld.Key == key {
+				return fld, true
+			}
+		}
+	case kindList, kindMultiSelect:
+		i, err := strconv.Atoi(key)
+		if err != nil || i < 0 {
+			return field{}, false
+		}
+		return itemField(parent, i), true
+	}
+	return field{}, false
+}
+
+// fieldAt returns the field of a path, e.g. "address.city" or "phones.0"
+func (f *EditForm) fieldAt(path string) (field, bool) {
+	keys := strings.Split(path, pathSeparator)
+	current, ok := f.field(keys[0])
+	for _, key := range keys[1:] {
+		if !ok {
+			break
+		}
+		current, ok = f.child(current, key)
+	}
+	return current, ok
+}
+
+// value returns the current value of the field of a path
+func (f *EditForm) value(path string) any {
+	return f.valueIn(f.data, path)
+}
+
+// valueIn returns the value of the field of a path in data, the zero value of the field for a nil parent
+func (f *EditForm) valueIn(data map[string]interface{}, path string) any {
+	keys := strings.Split(path, pathSeparator)
+	current, ok := f.field(keys[0])
+	if !ok {
+		return nil
+	}
+
+	v := reflect.ValueOf(data[keys[0]])
+	for _, key := range keys[1:] {
+		child, ok := f.child(current, key)
+		if !ok {
+			return nil
+		}
+		v = childValue(v, child)
+		current = child
+	}
+
+	if !v.IsValid() {
+		return reflect.Zero(current.Type).Interface()
+	}
+	return v.Interface()
+}
+
+// childValue returns the value of a field of a struct, or of an item of a slice, invalid if there is none
+func childValue(v reflect.Value, child field) reflect.Value {
+	v = indirect(v)
+	switch {
+	case !v.IsValid():
+	case v.Kind() == reflect.Struct:
+		return v.FieldByIndex(child.Index)
+	case v.Kind() == reflect.Slice:
+		if i, err := strconv.Atoi(child.Key); err == nil && i < v.Len() {
+			return v.Index(i)
+		}
+	}
+	return reflect.Value{}
+}
+
+// indirect returns the value a pointer points to, invalid for a nil pointer
+func indirect(v reflect.Value) reflect.Value {
+	if v.IsValid() && v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		return v.Elem()
+	}
+	return v
+}
+
+// setValue sets the value of the field of a path. The structs and slices on the path are copied,
+// so the initial values are never modified. An index one past the end of a slice appends the value.
+func (f *EditForm) setValue(path string, value any) error {
+	keys := strings.Split(path, pathSeparator)
+	top, ok := f.field(keys[0])
+	if !ok {
+		return fmt.Errorf("unknown field %s", keys[0])
+	}
+
+	v, err := f.setIn(top, reflect.ValueOf(f.data[keys[0]]), keys[1:], value)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	f.data[keys[0]] = v.Interface()
+	return nil
+}
+
+// setIn returns a copy of v, the value of the field current, with the value set at the keys
+func (f *EditForm) setIn(current field, v reflect.Value, keys []string, value any) (reflect.Value, error) {
+	if len(keys) == 0 {
+		nv := reflect.ValueOf(value)
+		if !nv.IsValid() {
+			return reflect.Zero(current.Type), nil
+		}
+		if !nv.Type().AssignableTo(current.Type) {
+			return reflect.Value{}, fmt.Errorf("%s is not a %s", nv.Type(), current.Type)
+		}
+		return nv, nil
+	}
+
+	child, ok := f.child(current, keys[0])
+	if !ok {
+		return reflect.Value{}, fmt.Errorf("unknown key %s", keys[0])
+	}
+
+	t := indirectType(current.Type)
+	base := indirect(v)
+	copied := reflect.New(t).Elem()
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if base.IsValid() {
+			copied.Set(base)
+		}
+		target := copied.FieldByIndex(child.Index)
+		nv, err := f.setIn(child, target, keys[1:], value)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		target.Set(nv)
+	case reflect.Slice:
+		n := 0
+		if base.IsValid() {
+			n = base.Len()
+		}
+		i, _ := strconv.Atoi(child.Key)
+		if i > n {
+			return reflect.Value{}, fmt.Errorf("index %d out of range", i)
+		}
+		length := n
+		if i == n {
+			length++
+		}
+		copied.Set(reflect.MakeSlice(t, length, length))
+		if base.IsValid() {
+			reflect.Copy(copied, base)
+		}
+		item := copied.Index(i)
+		nv, err := f.setIn(child, item, keys[1:], value)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		item.Set(nv)
+	default:
+		return reflect.Value{}, fmt.Errorf("%s has no key %s", current.Type, keys[0])
+	}
+
+	if current.Type.Kind() == reflect.Ptr {
+		return copied.Addr(), nil
+	}
+	return copied, nil
+}
+
+// setList sets the items of a list field
+func (f *EditForm) setList(list field, items reflect.Value) error {
+	if list.Type.Kind() == reflect.Ptr {
+		ptr := reflect.New(items.Type())
+		ptr.Elem().Set(items)
+		return f.setValue(list.path, ptr.Interface())
+	}
+	return f.setValue(list.path, items.Interface())
+}
+
+// items returns a copy of the items of a list field
+func (f *EditForm) items(list field) reflect.Value {
+	t := indirectType(list.Type)
+	current := indirect(reflect.ValueOf(f.value(list.path)))
+
+	items := reflect.MakeSlice(t, 0, 0)
+	if current.IsValid() {
+		items = reflect.AppendSlice(items, current)
+	}
+	return items
+}
+
+// openField returns the field of the open nested page
+func (f *EditForm) openField() (field, bool) {
+	if len(f.path) == 0 {
+		return field{}, false
+	}
+	return f.fieldAt(strings.Join(f.path, pathSeparator))
+}
+
+// options returns the values of a multi select field: the choices, the initial and the current items
+func (f *EditForm) options(list field) []string {
+	options := append([]string{}, list.Choices...)
+	add := func(value any) {
+		items := indirect(reflect.ValueOf(value))
+		for i := 0; items.IsValid() && i < items.Len(); i++ {
+			if option := fmt.Sprint(items.Index(i).Interface()); !contains(options, option) {
+				options = append(options, option)
+			}
+		}
+	}
+	add(f.valueIn(f.initialData, list.path))
+	add(f.value(list.path))
+	return options
+}
+
+// selected returns the current items of a multi select field as strings
+func (f *EditForm) selected(list field) []string {
+	items := f.items(list)
+	selected := make([]string, 0, items.Len())
+	for i := 0; i < items.Len(); i++ {
+		selected = append(selected, fmt.Sprint(items.Index(i).Interface()))
+	}
+	return selected
+}
+
+// toggle selects or deselects an option of a multi select field, the items keep the order of the options
+func (f *EditForm) toggle(list field, option string) error {
+	selected := f.selected(list)
+	if contains(selected, option) {
+		selected = remove(selected, option)
+	} else {
+		selected = append(selected, option)
+	}
+
+	t := indirectType(list.Type)
+	items := reflect.MakeSlice(t, 0, len(selected))
+	for _, option := range f.options(list) {
+		if !contains(selected, option) {
+			continue
+		}
+		item, err := parser.ParseValue(option, t.Elem())
+		if err != nil {
+			return err
+		}
+		items = reflect.Append(items, item)
+	}
+	return f.setList(list, items)
+}
+
+// moveItem swaps the item i of a list with the item j
+func (f *EditForm) moveItem(list field, i, j int) error {
+	items := f.items(list)
+	if i < 0 || j < 0 || i >= items.Len() || j >= items.Len() {
+		return nil
+	}
+	item := reflect.New(items.Type().Elem()).Elem()
+	item.Set(items.Index(i))
+	items.Index(i).Set(items.Index(j))
+	items.Index(j).Set(item)
+	return f.setList(list, items)
+}
+
+// removeItem removes the item i of a list
+func (f *EditForm) removeItem(list field, i int) error {
+	items := f.items(list)
+	if i < 0 || i >= items.Len() {
+		return nil
+	}
+	return f.setList(list, reflect.AppendSlice(items.Slice(0, i), items.Slice(i+1, items.Len())))
+}
+
+// nestedEntries returns the rows of the open nested page
+func (f *EditForm) nestedEntries(open field) []pageEntry {
+	entries := make([]pageEntry, 0)
+
+	switch open.kind {
+	case kindStruct:
+		for _, fld := range f.structFields(open) {
+			if fld.NoEdit || fld.ReadOnly || fld.kind == kindUnsupported {
+				continue
+			}
+			entries = append(entries, pageEntry{field: fld})
+		}
+	case kindList:
+		items := f.items(open)
+		for i := 0; i < items.Len(); i++ {
+			item := itemField(open, i)
+			text := fmt.Sprintf(TEXT_FORMAT_ITEM, i+1, item.displayValue(items.Index(i).Interface()))
+			if item.isContainer() {
+				text = fmt.Sprintf(TEXT_FORMAT_NESTED, text)
+			}
+			if f.hasError(item.path) {
+				text = fmt.Sprintf(TEXT_FORMAT_INVALID, text)
+			}
+			entries = append(entries, pageEntry{row: []button.Button{
+				{Text: text, CallbackData: f.callbackPrefix() + f.fieldCommand(item), OnClick: f.editCallback},
+				{Text: "⬆️", CallbackData: f.callbackPrefix() + "up_" + strconv.Itoa(i), OnClick: f.editCallback},
+				{Text: "⬇️", CallbackData: f.callbackPrefix() + "down_" + strconv.Itoa(i), OnClick: f.editCallback},
+				{Text: "🗑", CallbackData: f.callbackPrefix() + "remove_" + strconv.Itoa(i), OnClick: f.editCallback},
+			}})
+		}
+	case kindMultiSelect:
+		selected := f.selected(open)
+		for i, option := range f.options(open) {
+			text := TEXT_UNSELECTED + option
+			if contains(selected, option) {
+				text = TEXT_SELECTED + option
+			}
+			entries = append(entries, pageEntry{row: []button.Button{
+				{Text: text, CallbackData: f.callbackPrefix() + "toggle_" + strconv.Itoa(i), OnClick: f.editCallback},
+			}})
+		}
+	}
+
+	return entries
+}
+
+// addButton adds an item to the open list, or a value to the open multi select field without choices
+func (f *EditForm) addButton() []button.Button {
+	open, ok := f.openField()
+	if !ok || open.kind == kindStruct || (open.kind == kindMultiSelect && len(open.Choices) > 0) {
+		return nil
+	}
+	return []button.Button{{Text: "➕ Add", CallbackData: f.callbackPrefix() + "add", OnClick: f.editCallback}}
+}
+
+// fieldCommand opens the page of a nested struct or slice, or the editor of other fields
+func (f *EditForm) fieldCommand(fld field) string {
+	if fld.isContainer() {
+		return "open_" + fld.path
+	}
+	return "edit_" + fld.path
+}
+
+// nestedText returns the text of the button of a nested struct or slice
+func (f *EditForm) nestedText(fld field) string {
+	switch fld.kind {
+	case kindList:
+		return fmt.Sprintf(TEXT_FORMAT_LIST, fld.DisplayLabel(), f.items(fld).Len())
+	case kindMultiSelect:
+		return fmt.Sprintf(TEXT_FORMAT_MULTI, fld.DisplayLabel(), strings.Join(f.selected(fld), ", "))
+	}
+	return fmt.Sprintf(TEXT_FORMAT_NESTED, fld.DisplayLabel())
+}
+
+// breadcrumbs returns the open group and the labels of the open nested fields, e.g. "Address › Street"
+func (f *EditForm) breadcrumbs() string {
+	crumbs := make([]string, 0, len(f.path)+1)
+	if len(f.trail) > 0 && f.trail[0].group != "" {
+		crumbs = append(crumbs, f.trail[0].group)
+	}
+	for i := range f.path {
+		if fld, ok := f.fieldAt(strings.Join(f.path[:i+1], pathSeparator)); ok {
+			crumbs = append(crumbs, fld.DisplayLabel())
+		}
+	}
+	return strings.Join(crumbs, TEXT_BREADCRUMB)
+}
+
+// nested handles the commands of nested pages, it returns false for other commands
+func (f *EditForm) nested(command string) (bool, error) {
+	open, isOpen := f.openField()
+
+	switch {
+	case strings.HasPrefix(command, "open_"):
+		path := strings.TrimPrefix(command, "open_")
+		fld, ok := f.fieldAt(path)
+		if !ok || !fld.isContainer() {
+			return true, fmt.Errorf("unknown field %s", path)
+		}
+		f.openPath(path)
+	case command == "back" && len(f.path) > 0:
+		last := f.trail[len(f.trail)-1]
+		f.trail, f.path = f.trail[:len(f.trail)-1], f.path[:len(f.path)-1]
+		f.group, f.page = last.group, last.page
+	case !isOpen:
+		return false, nil
+	case strings.HasPrefix(command, "toggle_"):
+		options := f.options(open)
+		i, err := strconv.Atoi(strings.TrimPrefix(command, "toggle_"))
+		if err != nil || i < 0 || i >= len(options) {
+			return true, fmt.Errorf("invalid option %s", command)
+		}
+		return true, f.toggle(open, options[i])
+	case strings.HasPrefix(command, "up_"), strings.HasPrefix(command, "down_"), strings.HasPrefix(command, "remove_"):
+		action, index, _ := strings.Cut(command, "_")
+		i, err := strconv.Atoi(index)
+		if err != nil {
+			return true, fmt.Errorf("invalid item %s", command)
+		}
+		switch action {
+		case "up":
+			return true, f.moveItem(open, i, i-1)
+		case "down":
+			return true, f.moveItem(open, i, i+1)
+		}
+		return true, f.removeItem(open, i)
+	default:
+		return false, nil
+	}
+	return true, nil
+}
+
+// openPath opens the nested page of the path, Back returns to the current page.
+// The trail keeps a location per key of the path, e.g. for a stale button of a deeper path,
+// Back from a key not opened from the current page returns to the first page of its parent.
+func (f *EditForm) openPath(path string) {
+	keys := strings.Split(path, pathSeparator)
+	common := 0
+	for common < len(f.path) && common < len(keys) && f.path[common] == keys[common] {
+		common++
+	}
+
+	current := location{group: f.group, page: f.page}
+	f.trail = f.trail[:common]
+	for i := common; i < len(keys); i++ {
+		if i == common && common == len(f.path) {
+			f.trail = append(f.trail, current)
+		} else {
+			f.trail = append(f.trail, location{})
+		}
+	}
+	f.path = keys
+	f.group, f.page = "", 0
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func remove(values []string, value string) []string {
+	kept := make([]string, 0, len(values))
+	for _, v := range values {
+		if v != value {
+			kept = append(kept, v)
+		}
+	}
+	return kept
+}
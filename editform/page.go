@@ -0,0 +1,147 @@
+package editform
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/jkevinp/tgui/button"
+)
+
+const (
+	TEXT_FORMAT_GROUP        = "📂 %s ›"
+	TEXT_FORMAT_GROUP_EDITED = "🆕 📂 %s ›"
+	TEXT_FORMAT_PAGE         = "%d/%d"
+)
+
+// DefaultPageSize is the number of field buttons on a page of the form, see SetPageSize
+const DefaultPageSize = 8
+
+// SetPageSize sets the number of field and group buttons on a page of the form,
+// more are split across pages with previous and next buttons (default: DefaultPageSize).
+func (f *EditForm) SetPageSize(size int) *EditForm {
+	if size > 0 {
+		f.pageSize = size
+	}
+	return f
+}
+
+// pageEntry is a button of a field, of a group on the main page, or a row of a list item or option
+type pageEntry struct {
+	field field
+	group string
+	row   []button.Button
+}
+
+// pageEntries returns the entries of the open nested field, of the open group, or of the main page.
+// The main page has the fields without a group tag and a button per group at its first field.
+func (f *EditForm) pageEntries() []pageEntry {
+	if open, ok := f.openField(); ok {
+		return f.nestedEntries(open)
+	}
+
+	entries := make([]pageEntry, 0, len(f.fields))
+	seen := make(map[string]bool)
+
+	for _, field := range f.fields {
+		if field.NoEdit || field.ReadOnly || field.kind == kindUnsupported {
+			continue
+		}
+
+		switch {
+		case f.group != "":
+			if field.Group == f.group {
+				entries = append(entries, pageEntry{field: field})
+			}
+		case field.Group == "":
+			entries = append(entries, pageEntry{field: field})
+		case !seen[field.Group]:
+			seen[field.Group] = true
+			entries = append(entries, pageEntry{group: field.Group})
+		}
+	}
+
+	return entries
+}
+
+// pagesCount returns the number of pages of the entries
+func (f *EditForm) pagesCount(entries int) int {
+	if entries == 0 {
+		return 1
+	}
+	return (entries + f.pageSize - 1) / f.pageSize
+}
+
+// groupEdited reports whether a field of the group was changed
+func (f *EditForm) groupEdited(group string) bool {
+	for _, field := range f.fields {
+		if field.Group == group && !reflect.DeepEqual(f.initialData[field.Key], f.data[field.Key]) {
+			return true
+		}
+	}
+	return false
+}
+
+// groupButton opens the sub-page of a group
+func (f *EditForm) groupButton(group string) button.Button {
+	fmtToUse := TEXT_FORMAT_GROUP
+	if f.groupEdited(group) {
+		fmtToUse = TEXT_FORMAT_GROUP_EDITED
+	}
+	text := fmt.Sprintf(fmtToUse, group)
+	for _, field := range f.fields {
+		if field.Group == group && f.hasError(field.path) {
+			text = fmt.Sprintf(TEXT_FORMAT_INVALID, text)
+			break
+		}
+	}
+
+	return button.Button{
+		Text:         text,
+		CallbackData: f.callbackPrefix() + "group_" + group,
+		OnClick:      f.editCallback,
+	}
+}
+
+// navigationRow returns the previous and next page buttons, nil for a single page
+func (f *EditForm) navigationRow(pages int) []button.Button {
+	if pages < 2 {
+		return nil
+	}
+
+	prev, next := f.page-1, f.page+1
+	if prev < 0 {
+		prev = pages - 1
+	}
+	if next >= pages {
+		next = 0
+	}
+
+	return []button.Button{
+		{Text: "‹", CallbackData: f.callbackPrefix() + "page_" + strconv.Itoa(prev), OnClick: f.editCallback},
+		{Text: fmt.Sprintf(TEXT_FORMAT_PAGE, f.page+1, pages), CallbackData: f.callbackPrefix() + "page_" + strconv.Itoa(f.page), OnClick: f.editCallback},
+		{Text: "›", CallbackData: f.callbackPrefix() + "page_" + strconv.Itoa(next), OnClick: f.editCallback},
+	}
+}
+
+// navigate handles the group, back and page commands, it returns false for other commands
+func (f *EditForm) navigate(command string) bool {
+	switch {
+	case strings.HasPrefix(command, "group_"):
+		f.parentPage = f.page
+		f.group, f.page = strings.TrimPrefix(command, "group_"), 0
+	case command == "back":
+		f.group, f.page = "", f.parentPage
+	case strings.HasPrefix(command, "page_"):
+		page, err := strconv.Atoi(strings.TrimPrefix(command, "page_"))
+		if err != nil {
+			fmt.Println("[EditForm.navigate] invalid page:", command)
+			return true
+		}
+		f.page = page
+	default:
+		return false
+	}
+	return true
+}
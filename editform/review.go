@@ -0,0 +1,126 @@
+package editform
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/jkevinp/tgui/button"
+)
+
+const (
+	TEXT_FORMAT_CHANGE = "%s: %v → %v"
+	TEXT_REVIEW        = "Review changes:"
+)
+
+// changedFields returns the fields with a value different from the initial one, in field order
+func (f *EditForm) changedFields() []field {
+	changed := make([]field, 0)
+	for _, field := range f.fields {
+		if !reflect.DeepEqual(f.initialData[field.Key], f.data[field.Key]) {
+			changed = append(changed, field)
+		}
+	}
+	return changed
+}
+
+// patch returns the changed values by key
+func (f *EditForm) patch() map[string]interface{} {
+	patch := make(map[string]interface{})
+	for _, field := range f.changedFields() {
+		patch[field.Key] = f.data[field.Key]
+	}
+	return patch
+}
+
+// formatValue formats a value of the field for a button or the review, with the formatter of the path if set
+func (f *EditForm) formatValue(field field, value any) (string, error) {
+	text := field.displayValue(value)
+	if f.stringFormatter[field.path] == nil {
+		return text, nil
+	}
+	return f.stringFormatter[field.path](text)
+}
+
+// reviewButton opens the review of the changes, nil without changes
+func (f *EditForm) reviewButton() []button.Button {
+	changed := len(f.changedFields())
+	if changed == 0 {
+		return nil
+	}
+
+	return []button.Button{{
+		Text:         fmt.Sprintf("🔍 Review changes (%d)", changed),
+		CallbackData: f.callbackPrefix() + "review",
+		OnClick:      f.editCallback,
+	}}
+}
+
+// reviewText lists the old and new value of each changed field
+func (f *EditForm) reviewText() string {
+	lines := []string{f.text, TEXT_REVIEW}
+	for _, field := range f.changedFields() {
+		lines = append(lines, fmt.Sprintf(TEXT_FORMAT_CHANGE, field.DisplayLabel(),
+			f.reviewValue(field, f.initialData[field.Key]), f.reviewValue(field, f.data[field.Key])))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// reviewValue formats a value for the review, unformatted if the formatter fails
+func (f *EditForm) reviewValue(field field, value any) string {
+	text, err := f.formatValue(field, value)
+	if err != nil {
+		fmt.Println("[EditForm.reviewValue] format", field.Key, err)
+		return field.displayValue(value)
+	}
+	return text
+}
+
+// reviewControls returns the revert buttons of the changed fields followed by Reset all and Back
+func (f *EditForm) reviewControls() *button.ButtonGrid {
+	review := button.NewBuilder()
+
+	for _, field := range f.changedFields() {
+		review.Row().Add(button.Button{
+			Text:         "↩️ Revert " + field.DisplayLabel(),
+			CallbackData: f.callbackPrefix() + "revert_" + field.Key,
+			OnClick:      f.editCallback,
+		})
+	}
+	review.Row().Add(button.Button{
+		Text:         "♻️ Reset all",
+		CallbackData: f.callbackPrefix() + "reset",
+		OnClick:      f.editCallback,
+	}).Add(button.Button{
+		Text:         "‹ Back",
+		CallbackData: f.callbackPrefix() + "back",
+		OnClick:      f.editCallback,
+	})
+
+	return review
+}
+
+// review handles the review, revert, reset and back from review commands, it returns false for other commands
+func (f *EditForm) review(command string) bool {
+	switch {
+	case command == "review":
+		f.reviewing = true
+	case command == "back" && f.reviewing:
+		f.reviewing = false
+	case strings.HasPrefix(command, "revert_"):
+		key := strings.TrimPrefix(command, "revert_")
+		if _, ok := f.field(key); !ok {
+			fmt.Println("[EditForm.review] unknown field:", key)
+			return true
+		}
+		f.data[key] = f.initialData[key]
+	case command == "reset":
+		for key, value := range f.initialData {
+			f.data[key] = value
+		}
+		f.reviewing = false
+	default:
+		return false
+	}
+	return true
+}
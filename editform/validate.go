@@ -0,0 +1,155 @@
+package editform
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+const (
+	TEXT_FORMAT_INVALID = "⚠️ %s"
+	TEXT_INVALID        = "Please correct the fields marked ⚠️"
+)
+
+// Validator checks the value of a field, typed like the field, e.g. an int for an int field.
+type Validator func(value any) error
+
+// fieldError is the error of the field of a path
+type fieldError struct {
+	path string
+	err  error
+}
+
+// SetValidator sets a validator of the field of the key, the path of a nested field, e.g. "address.city".
+// It runs after the validation of the tags on every edit, the answer is asked again on an error,
+// and again on Done for every field, Done is blocked until all fields are valid.
+func (f *EditForm) SetValidator(key string, validate Validator) *EditForm {
+	f.validators[key] = validate
+	return f
+}
+
+// validate returns the errors of the editable fields and their nested fields, in field order
+func (f *EditForm) validate() []fieldError {
+	errs := make([]fieldError, 0)
+	for _, field := range f.fields {
+		errs = f.validateField(field, errs)
+	}
+	return errs
+}
+
+// validateField appends the errors of the tags and the validator of a field, and of its nested fields
+func (f *EditForm) validateField(field field, errs []fieldError) []fieldError {
+	if field.NoEdit || field.ReadOnly || field.kind == kindUnsupported {
+		return errs
+	}
+	value := f.value(field.path)
+
+	switch field.kind {
+	case kindStruct:
+		if dereference(value) == nil {
+			if field.Required {
+				errs = append(errs, fieldError{field.path, fmt.Errorf("%s is required", field.DisplayLabel())})
+			}
+			break
+		}
+		for _, child := range f.structFields(field) {
+			errs = f.validateField(child, errs)
+		}
+	case kindList, kindMultiSelect:
+		if err := validateCount(field, f.items(field).Len()); err != nil {
+			errs = append(errs, fieldError{field.path, err})
+		}
+		for i := 0; field.kind == kindList && i < f.items(field).Len(); i++ {
+			errs = f.validateField(itemField(field, i), errs)
+		}
+	default:
+		text := validationText(field, value)
+		if text == "" && !field.Required {
+			break
+		}
+		if err := field.Validate(text); err != nil {
+			errs = append(errs, fieldError{field.path, err})
+		}
+	}
+
+	if validate := f.validators[field.path]; validate != nil {
+		if err := validate(value); err != nil {
+			errs = append(errs, fieldError{field.path, fmt.Errorf("%s: %w", field.DisplayLabel(), err)})
+		}
+	}
+	return errs
+}
+
+// validateCount checks the required, min and max tags of a slice against the number of items
+func validateCount(field field, n int) error {
+	switch {
+	case field.Required && n == 0:
+		return fmt.Errorf("%s is required", field.DisplayLabel())
+	case field.Min != nil && float64(n) < *field.Min:
+		return fmt.Errorf("%s must have at least %v items", field.DisplayLabel(), *field.Min)
+	case field.Max != nil && float64(n) > *field.Max:
+		return fmt.Errorf("%s must have at most %v items", field.DisplayLabel(), *field.Max)
+	}
+	return nil
+}
+
+// validationText returns the value as an answer to the field, empty for a nil pointer, an empty string or a zero time
+func validationText(field field, value any) string {
+	switch v := dereference(value).(type) {
+	case nil:
+		return ""
+	case time.Time:
+		if v.IsZero() {
+			return ""
+		}
+		return field.FormatValue(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// visibleErrors returns the errors of the changed fields, or of all fields once Done was clicked
+func (f *EditForm) visibleErrors() []fieldError {
+	errs := f.validate()
+	if f.submitted {
+		return errs
+	}
+
+	visible := make([]fieldError, 0, len(errs))
+	for _, e := range errs {
+		if !reflect.DeepEqual(f.valueIn(f.initialData, e.path), f.value(e.path)) {
+			visible = append(visible, e)
+		}
+	}
+	return visible
+}
+
+// hasError reports whether the field of the path, or one of its nested fields, has a visible error
+func (f *EditForm) hasError(path string) bool {
+	for _, e := range f.errors {
+		if e.path == path || strings.HasPrefix(e.path, path+pathSeparator) {
+			return true
+		}
+	}
+	return false
+}
+
+// errorText lists the visible errors and the error of the done handlers below the form text
+func (f *EditForm) errorText() string {
+	lines := make([]string, 0, len(f.errors)+2)
+	if len(f.errors) > 0 {
+		lines = append(lines, TEXT_INVALID)
+	}
+	for _, e := range f.errors {
+		lines = append(lines, fmt.Sprintf(TEXT_FORMAT_INVALID, e.err))
+	}
+	if f.doneErr != nil {
+		lines = append(lines, fmt.Sprintf(TEXT_FORMAT_INVALID, f.doneErr))
+	}
+
+	if len(lines) == 0 {
+		return ""
+	}
+	return "\n" + strings.Join(lines, "\n")
+}
@@ -0,0 +1,118 @@
+/*
+Package testbot runs a fake Telegram Bot API server for the tests of the widgets.
+It records every request and answers it like Telegram would, without a network or a token.
+*/
+package testbot
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"sync"
+	"testing"
+
+	"github.com/go-telegram/bot"
+)
+
+const (
+	// MessageID is the ID of every message sent or edited through the fake server
+	MessageID = 1
+	// ChatID is the chat of every message sent or edited through the fake server
+	ChatID = 42
+
+	messageResult = `{"ok":true,"result":{"message_id":1,"chat":{"id":42}}}`
+	boolResult    = `{"ok":true,"result":true}`
+)
+
+// Request is a request received by the fake server, the API method and the raw body
+type Request struct {
+	Method string
+	Body   string
+}
+
+// Server records the requests of a bot, it is safe for concurrent use.
+type Server struct {
+	mu       sync.Mutex
+	requests []Request
+}
+
+/*
+New returns a bot backed by a fake API server, closed when the test ends.
+Callback answers and deletes get true, the other methods a message with MessageID in ChatID.
+The options are added to the bot's, e.g. bot.WithNotAsyncHandlers to process updates in the test goroutine.
+*/
+func New(t testing.TB, opts ...bot.Option) (*bot.Bot, *Server) {
+	t.Helper()
+
+	s := &Server{}
+	server := httptest.NewServer(http.HandlerFunc(s.serve))
+	t.Cleanup(server.Close)
+
+	opts = append([]bot.Option{bot.WithSkipGetMe(), bot.WithServerURL(server.URL)}, opts...)
+	b, err := bot.New("123:token", opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b, s
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	method := path.Base(r.URL.Path)
+
+	s.mu.Lock()
+	s.requests = append(s.requests, Request{Method: method, Body: string(body)})
+	s.mu.Unlock()
+
+	switch method {
+	case "answerCallbackQuery", "deleteMessage", "deleteMessages":
+		w.Write([]byte(boolResult))
+	default:
+		w.Write([]byte(messageResult))
+	}
+}
+
+// Requests returns the requests received since the start or the last Reset
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// Methods returns the API methods of the requests, in order
+func (s *Server) Methods() []string {
+	requests := s.Requests()
+	methods := make([]string, 0, len(requests))
+	for _, r := range requests {
+		methods = append(methods, r.Method)
+	}
+	return methods
+}
+
+// Bodies returns the bodies of the requests of the method, all requests for an empty method
+func (s *Server) Bodies(method string) []string {
+	bodies := make([]string, 0)
+	for _, r := range s.Requests() {
+		if method == "" || r.Method == method {
+			bodies = append(bodies, r.Body)
+		}
+	}
+	return bodies
+}
+
+// Last returns the body of the last request, empty without requests
+func (s *Server) Last() string {
+	requests := s.Requests()
+	if len(requests) == 0 {
+		return ""
+	}
+	return requests[len(requests)-1].Body
+}
+
+// Reset forgets the recorded requests
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = nil
+}
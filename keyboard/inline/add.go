@@ -22,7 +22,7 @@ func (kb *Keyboard) Button(text string, data []byte, handler OnSelect) *Keyboard
 
 	kb.markup[len(kb.markup)-1] = append(kb.markup[len(kb.markup)-1], models.InlineKeyboardButton{
 		Text:         text,
-		CallbackData: kb.prefix + strconv.Itoa(len(kb.handlers)-1),
+		CallbackData: kb.signer.Sign(kb.prefix + strconv.Itoa(len(kb.handlers)-1)),
 	})
 
 	return kb
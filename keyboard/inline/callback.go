@@ -8,11 +8,17 @@ import (
 
 	"github.com/go-telegram/bot"
 	"github.com/go-telegram/bot/models"
+	"github.com/jkevinp/tgui/callbackdata"
+	"github.com/jkevinp/tgui/router"
 )
 
-func (kb *Keyboard) callbackAnswer(ctx context.Context, b *bot.Bot, callbackQuery *models.CallbackQuery) {
+func (kb *Keyboard) callbackAnswer(ctx context.Context, b *bot.Bot, callbackQuery *models.CallbackQuery, text string) {
+	if router.Answer(ctx, text) {
+		return
+	}
 	ok, err := b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
 		CallbackQueryID: callbackQuery.ID,
+		Text:            text,
 	})
 	if err != nil {
 		kb.onError(err)
@@ -24,8 +30,15 @@ func (kb *Keyboard) callbackAnswer(ctx context.Context, b *bot.Bot, callbackQuer
 }
 
 func (kb *Keyboard) callback(ctx context.Context, b *bot.Bot, update *models.Update) {
+	data, errVerify := kb.signer.Verify(update.CallbackQuery.Data)
+	if errVerify != nil {
+		kb.onError(fmt.Errorf("%w, %s", errVerify, update.CallbackQuery.Data))
+		kb.callbackAnswer(ctx, b, update.CallbackQuery, callbackdata.InvalidButtonText)
+		return
+	}
+
 	if kb.deleteAfterClick {
-		b.UnregisterHandler(kb.callbackHandlerID)
+		kb.unregister(b)
 
 		_, errDelete := b.DeleteMessage(ctx, &bot.DeleteMessageParams{
 			ChatID:    update.CallbackQuery.Message.Message.Chat.ID,
@@ -36,22 +49,28 @@ func (kb *Keyboard) callback(ctx context.Context, b *bot.Bot, update *models.Upd
 		}
 	}
 
-	btnNum, errBtnNum := strconv.Atoi(strings.TrimPrefix(update.CallbackQuery.Data, kb.prefix))
+	btnNum, errBtnNum := strconv.Atoi(strings.TrimPrefix(data, kb.prefix))
 	if errBtnNum != nil {
 		kb.onError(fmt.Errorf("wrong callback data btnNum, %s", update.CallbackQuery.Data))
-		kb.callbackAnswer(ctx, b, update.CallbackQuery)
+		kb.callbackAnswer(ctx, b, update.CallbackQuery, callbackdata.InvalidButtonText)
 		return
 	}
 
-	if len(kb.handlers) <= btnNum {
+	if btnNum < 0 || len(kb.handlers) <= btnNum {
 		kb.onError(fmt.Errorf("wrong callback data, %s", update.CallbackQuery.Data))
-		kb.callbackAnswer(ctx, b, update.CallbackQuery)
+		kb.callbackAnswer(ctx, b, update.CallbackQuery, callbackdata.InvalidButtonText)
 		return
 	}
 
+	if kb.answerBeforeClick {
+		kb.callbackAnswer(ctx, b, update.CallbackQuery, "")
+	}
+
 	if kb.handlers[btnNum].Handler != nil {
 		kb.handlers[btnNum].Handler(ctx, b, update.CallbackQuery.Message, kb.handlers[btnNum].data)
 	}
 
-	kb.callbackAnswer(ctx, b, update.CallbackQuery)
+	if !kb.answerBeforeClick {
+		kb.callbackAnswer(ctx, b, update.CallbackQuery, "")
+	}
 }
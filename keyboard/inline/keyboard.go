@@ -7,6 +7,8 @@ import (
 
 	"github.com/go-telegram/bot"
 	"github.com/go-telegram/bot/models"
+	"github.com/jkevinp/tgui/callbackdata"
+	"github.com/jkevinp/tgui/router"
 )
 
 type OnSelect func(ctx context.Context, bot *bot.Bot, mes models.MaybeInaccessibleMessage, data []byte)
@@ -19,8 +21,11 @@ type handlerData struct {
 
 type Keyboard struct {
 	// configurable
-	onError          OnErrorHandler
-	deleteAfterClick bool
+	onError           OnErrorHandler
+	deleteAfterClick  bool
+	answerBeforeClick bool
+	router            *router.Router
+	signer            *callbackdata.Signer
 
 	// internal
 	prefix            string
@@ -42,16 +47,34 @@ func New(b *bot.Bot, opts ...Option) *Keyboard {
 		opt(kb)
 	}
 
-	kb.callbackHandlerID = b.RegisterHandler(bot.HandlerTypeCallbackQueryData, kb.prefix, bot.MatchTypePrefix, kb.callback)
+	if kb.router != nil {
+		kb.callbackHandlerID, kb.prefix = kb.router.Handle("kb", kb.callback)
+	} else {
+		kb.callbackHandlerID = b.RegisterHandler(bot.HandlerTypeCallbackQueryData, kb.prefix, bot.MatchTypePrefix, kb.callback)
+	}
 
 	return kb
 }
 
+// unregister removes the widget's handler from the router or the bot
+func (kb *Keyboard) unregister(b *bot.Bot) {
+	if kb.router != nil {
+		kb.router.Remove(kb.callbackHandlerID)
+		return
+	}
+	b.UnregisterHandler(kb.callbackHandlerID)
+}
+
 // Prefix returns the prefix of the widget
 func (kb *Keyboard) Prefix() string {
 	return kb.prefix
 }
 
+// GetCallbackHandlerID returns the ID of the bot handler registered for the widget
+func (kb *Keyboard) GetCallbackHandlerID() string {
+	return kb.callbackHandlerID
+}
+
 func (kb *Keyboard) MarshalJSON() ([]byte, error) {
 	return json.Marshal(models.InlineKeyboardMarkup{InlineKeyboard: kb.markup})
 }
@@ -1,5 +1,10 @@
 package inline
 
+import (
+	"github.com/jkevinp/tgui/callbackdata"
+	"github.com/jkevinp/tgui/router"
+)
+
 type Option func(kb *Keyboard)
 
 // NoDeleteAfterClick is a keyboard option that prevents the hide keyboard after click.
@@ -9,6 +14,14 @@ func NoDeleteAfterClick() Option {
 	}
 }
 
+// AnswerBeforeClick is a keyboard option that answers the callback query before calling the button handler,
+// so the button stops spinning while a slow handler runs.
+func AnswerBeforeClick() Option {
+	return func(kb *Keyboard) {
+		kb.answerBeforeClick = true
+	}
+}
+
 // OnError is a keyboard option that sets a callback function to be called when an error occurs.
 func OnError(f func(err error)) Option {
 	return func(kb *Keyboard) {
@@ -22,3 +35,18 @@ func WithPrefix(s string) Option {
 		w.prefix = s
 	}
 }
+
+// WithRouter dispatches the widget's callbacks through the router instead of registering its own bot handler.
+// The router assigns the prefix, so WithPrefix has no effect.
+func WithRouter(r *router.Router) Option {
+	return func(kb *Keyboard) {
+		kb.router = r
+	}
+}
+
+// WithSigningKey is a keyboard option that signs the callback data with the key, clicks with tampered data are rejected.
+func WithSigningKey(key []byte) Option {
+	return func(kb *Keyboard) {
+		kb.signer = callbackdata.NewSigner(key)
+	}
+}
@@ -8,11 +8,17 @@ import (
 
 	"github.com/go-telegram/bot"
 	"github.com/go-telegram/bot/models"
+	"github.com/jkevinp/tgui/callbackdata"
+	"github.com/jkevinp/tgui/router"
 )
 
-func (p *Paginator) callbackAnswer(ctx context.Context, b *bot.Bot, callbackQuery *models.CallbackQuery) {
+func (p *Paginator) callbackAnswer(ctx context.Context, b *bot.Bot, callbackQuery *models.CallbackQuery, text string) {
+	if router.Answer(ctx, text) {
+		return
+	}
 	ok, err := b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
 		CallbackQueryID: callbackQuery.ID,
+		Text:            text,
 	})
 	if err != nil {
 		p.onError(err)
@@ -24,26 +30,32 @@ func (p *Paginator) callbackAnswer(ctx context.Context, b *bot.Bot, callbackQuer
 }
 
 func (p *Paginator) callback(ctx context.Context, b *bot.Bot, update *models.Update) {
-	cmd := strings.TrimPrefix(update.CallbackQuery.Data, p.prefix)
+	data, errVerify := p.signer.Verify(update.CallbackQuery.Data)
+	if errVerify != nil {
+		p.onError(fmt.Errorf("%w, %s", errVerify, update.CallbackQuery.Data))
+		p.callbackAnswer(ctx, b, update.CallbackQuery, callbackdata.InvalidButtonText)
+		return
+	}
+	cmd := strings.TrimPrefix(data, p.prefix)
 
 	switch cmd {
 	case cmdNop:
-		p.callbackAnswer(ctx, b, update.CallbackQuery)
+		p.callbackAnswer(ctx, b, update.CallbackQuery, "")
 		return
 	case cmdStart:
 		if p.currentPage == 1 {
-			p.callbackAnswer(ctx, b, update.CallbackQuery)
+			p.callbackAnswer(ctx, b, update.CallbackQuery, "")
 			return
 		}
 		p.currentPage = 1
 	case cmdEnd:
 		if p.currentPage == p.pagesCount {
-			p.callbackAnswer(ctx, b, update.CallbackQuery)
+			p.callbackAnswer(ctx, b, update.CallbackQuery, "")
 			return
 		}
 		p.currentPage = p.pagesCount
 	case cmdClose:
-		b.UnregisterHandler(p.callbackHandlerID)
+		p.unregister(b)
 
 		_, errDelete := b.DeleteMessage(ctx, &bot.DeleteMessageParams{
 			ChatID:    update.CallbackQuery.Message.Message.Chat.ID,
@@ -52,10 +64,15 @@ func (p *Paginator) callback(ctx context.Context, b *bot.Bot, update *models.Upd
 		if errDelete != nil {
 			p.onError(errDelete)
 		}
-		p.callbackAnswer(ctx, b, update.CallbackQuery)
+		p.callbackAnswer(ctx, b, update.CallbackQuery, "")
 		return
 	default:
-		page, _ := strconv.Atoi(cmd)
+		page, errPage := strconv.Atoi(cmd)
+		if errPage != nil || page < 1 || page > p.pagesCount {
+			p.onError(fmt.Errorf("wrong callback data page, %s", update.CallbackQuery.Data))
+			p.callbackAnswer(ctx, b, update.CallbackQuery, callbackdata.InvalidButtonText)
+			return
+		}
 		p.currentPage = page
 	}
 
@@ -71,5 +88,5 @@ func (p *Paginator) callback(ctx context.Context, b *bot.Bot, update *models.Upd
 		p.onError(errEdit)
 	}
 
-	p.callbackAnswer(ctx, b, update.CallbackQuery)
+	p.callbackAnswer(ctx, b, update.CallbackQuery, "")
 }
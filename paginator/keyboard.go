@@ -18,11 +18,11 @@ func (p *Paginator) buildKeyboard() models.InlineKeyboardMarkup {
 					buttonText = "( " + buttonText + " )"
 				}
 
-				row = append(row, models.InlineKeyboardButton{Text: buttonText, CallbackData: p.prefix + callbackCommand})
+				row = append(row, models.InlineKeyboardButton{Text: buttonText, CallbackData: p.callbackData(callbackCommand)})
 			}
 		}
 	} else {
-		row = append(row, models.InlineKeyboardButton{Text: "\u00AB 1", CallbackData: p.prefix + cmdStart})
+		row = append(row, models.InlineKeyboardButton{Text: "\u00AB 1", CallbackData: p.callbackData(cmdStart)})
 
 		startPage := p.calcStartPage()
 
@@ -37,10 +37,10 @@ func (p *Paginator) buildKeyboard() models.InlineKeyboardMarkup {
 				buttonText = "( " + buttonText + " )"
 			}
 
-			row = append(row, models.InlineKeyboardButton{Text: buttonText, CallbackData: p.prefix + callbackCommand})
+			row = append(row, models.InlineKeyboardButton{Text: buttonText, CallbackData: p.callbackData(callbackCommand)})
 		}
 
-		row = append(row, models.InlineKeyboardButton{Text: strconv.Itoa(p.pagesCount) + " \u00BB", CallbackData: p.prefix + cmdEnd})
+		row = append(row, models.InlineKeyboardButton{Text: strconv.Itoa(p.pagesCount) + " \u00BB", CallbackData: p.callbackData(cmdEnd)})
 	}
 
 	kb := models.InlineKeyboardMarkup{
@@ -53,7 +53,7 @@ func (p *Paginator) buildKeyboard() models.InlineKeyboardMarkup {
 
 	if p.closeButton != "" {
 		kb.InlineKeyboard = append(kb.InlineKeyboard, []models.InlineKeyboardButton{
-			{Text: p.closeButton, CallbackData: p.prefix + cmdClose},
+			{Text: p.closeButton, CallbackData: p.callbackData(cmdClose)},
 		})
 	}
 
@@ -72,3 +72,8 @@ func (p *Paginator) calcStartPage() int {
 	}
 	return p.currentPage - 2
 }
+
+// callbackData returns the callback data of the command, signed if a signing key is set
+func (p *Paginator) callbackData(cmd string) string {
+	return p.signer.Sign(p.prefix + cmd)
+}
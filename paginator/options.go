@@ -1,5 +1,10 @@
 package paginator
 
+import (
+	"github.com/jkevinp/tgui/callbackdata"
+	"github.com/jkevinp/tgui/router"
+)
+
 type Option func(p *Paginator)
 
 // PerPage sets the number of items to be displayed per page.
@@ -43,3 +48,18 @@ func WithoutEmptyButtons() Option {
 		p.withoutEmptyButtons = true
 	}
 }
+
+// WithRouter dispatches the widget's callbacks through the router instead of registering its own bot handler.
+// The router assigns the prefix, so WithPrefix has no effect.
+func WithRouter(r *router.Router) Option {
+	return func(p *Paginator) {
+		p.router = r
+	}
+}
+
+// WithSigningKey is a paginator option that signs the callback data with the key, clicks with tampered data are rejected.
+func WithSigningKey(key []byte) Option {
+	return func(p *Paginator) {
+		p.signer = callbackdata.NewSigner(key)
+	}
+}
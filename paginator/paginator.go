@@ -6,6 +6,8 @@ import (
 
 	"github.com/go-telegram/bot"
 	"github.com/go-telegram/bot/models"
+	"github.com/jkevinp/tgui/callbackdata"
+	"github.com/jkevinp/tgui/router"
 )
 
 type OnErrorHandler func(err error)
@@ -27,6 +29,8 @@ type Paginator struct {
 	closeButton         string
 	onError             OnErrorHandler
 	withoutEmptyButtons bool
+	router              *router.Router
+	signer              *callbackdata.Signer
 
 	callbackHandlerID string
 }
@@ -50,11 +54,24 @@ func New(b *bot.Bot, data []string, opts ...Option) *Paginator {
 		p.pagesCount++
 	}
 
-	p.callbackHandlerID = b.RegisterHandler(bot.HandlerTypeCallbackQueryData, p.prefix, bot.MatchTypePrefix, p.callback)
+	if p.router != nil {
+		p.callbackHandlerID, p.prefix = p.router.Handle("pg", p.callback)
+	} else {
+		p.callbackHandlerID = b.RegisterHandler(bot.HandlerTypeCallbackQueryData, p.prefix, bot.MatchTypePrefix, p.callback)
+	}
 
 	return p
 }
 
+// unregister removes the widget's handler from the router or the bot
+func (p *Paginator) unregister(b *bot.Bot) {
+	if p.router != nil {
+		p.router.Remove(p.callbackHandlerID)
+		return
+	}
+	b.UnregisterHandler(p.callbackHandlerID)
+}
+
 // Prefix returns the prefix of the widget
 func (p *Paginator) Prefix() string {
 	return p.prefix
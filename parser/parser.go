@@ -6,6 +6,8 @@ import (
 	"strings"
 )
 
+// ParseTGTags returns the tg tags of the struct fields by JSON key, flags have the value "true".
+// Malformed tags are returned as errors wrapping ErrInvalidTag, see ParseFieldSpecs for the tag vocabulary.
 func ParseTGTags(v interface{}) (map[string]map[string]string, error) {
 	val := reflect.ValueOf(v)
 	if val.Kind() == reflect.Ptr {
@@ -23,22 +25,17 @@ func ParseTGTags(v interface{}) (map[string]map[string]string, error) {
 		field := val.Type().Field(i)
 		tag := field.Tag.Get("tg")
 		// fmt.Printf("Field: %s, tg Tag: %s\n", field.Name, tag)
-		tags := strings.Split(tag, ";")
 		key := field.Name
+		entries, err := parseTag(tag)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", field.Name, err)
+		}
 		tagsMap := make(map[string]string)
-		for _, t := range tags {
-
-			parts := strings.Split(t, ":")
-
-			if len(parts) == 2 {
-				// fmt.Printf("    %s: %s\n", parts[0], parts[1])
-				tagsMap[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+		for _, entry := range entries {
+			if entry[1] == "" {
+				tagsMap[entry[0]] = "true"
 			} else {
-				// fmt.Printf("    %s\n", parts[0])
-				if t != "" {
-					tagsMap[t] = "true"
-				}
-
+				tagsMap[entry[0]] = entry[1]
 			}
 		}
 
@@ -0,0 +1,469 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Tag keys of the tg struct tag vocabulary.
+//
+//	type User struct {
+//		Name  string `json:"name" tg:"label:Full name;required;max:64"`
+//		Email string `json:"email" tg:"regex:^[^@]+@[^@]+$;help:We never share it"`
+//		Role  string `json:"role" tg:"choices:admin|editor|viewer"`
+//		Start string `json:"start" tg:"format:15\\:04"`
+//	}
+//
+// Entries are separated by ";", a key and its value by ":". Use "\;", "\:" and "\\" inside values;
+// struct tag values are quoted strings, so the backslash is doubled in the source, e.g. `tg:"format:15\\:04"`.
+const (
+	TagLabel    = "label"    // Name shown instead of the field key
+	TagOrder    = "order"    // Position of the field, lower first; fields without order keep declaration order after them
+	TagRequired = "required" // The answer must not be empty
+	TagMin      = "min"      // Minimum of a number, or minimum length of a text
+	TagMax      = "max"      // Maximum of a number, or maximum length of a text
+	TagRegex    = "regex"    // The answer must match the regular expression
+	TagChoices  = "choices"  // Allowed values separated by "|"
+	TagFormat   = "format"   // Go time layout of a time.Time, or fmt verb of other values, e.g. %.2f
+	TagHelp     = "help"     // Hint shown with the question
+	TagGroup    = "group"    // Section of the field, shown as a sub-page of an EditForm
+	TagSecret   = "secret"   // The value is masked when displayed
+	TagReadOnly = "readonly" // The value is shown but can't be edited
+	TagNoEdit   = "noedit"   // The field is not shown
+)
+
+// Enum is implemented by field types with a fixed set of values.
+// ParseFieldSpecs uses the values as choices of a field of the type, or of a slice of the type, without a choices tag.
+type Enum interface {
+	EnumValues() []string
+}
+
+// choicesSeparator separates the values of the choices tag
+const choicesSeparator = "|"
+
+// ErrInvalidTag is wrapped by the errors of malformed tg struct tags.
+var ErrInvalidTag = errors.New("invalid tg tag")
+
+// flagTags are the tag keys without a value
+var flagTags = map[string]bool{
+	TagRequired: true,
+	TagSecret:   true,
+	TagReadOnly: true,
+	TagNoEdit:   true,
+}
+
+// valueTags are the tag keys requiring a value
+var valueTags = map[string]bool{
+	TagLabel:   true,
+	TagOrder:   true,
+	TagMin:     true,
+	TagMax:     true,
+	TagRegex:   true,
+	TagChoices: true,
+	TagFormat:  true,
+	TagHelp:    true,
+	TagGroup:   true,
+}
+
+// FieldSpec describes an exported struct field and the tg tags set on it.
+type FieldSpec struct {
+	Key   string       // JSON name of the field, or the field name
+	Name  string       // Go name of the field
+	Index []int        // Index of the field for reflect.Value.FieldByIndex
+	Type  reflect.Type // Type of the field
+
+	Label    string
+	Order    int
+	Required bool
+	Min      *float64
+	Max      *float64
+	Regex    *regexp.Regexp
+	Choices  []string
+	Format   string
+	Help     string
+	Group    string
+	Secret   bool
+	ReadOnly bool
+	NoEdit   bool
+}
+
+/*
+ParseFieldSpecs returns the specs of the exported fields of a struct, sorted by the order tag.
+Fields with the JSON tag "-" are left out. Unknown tag keys and malformed values are returned as errors wrapping ErrInvalidTag.
+*/
+func ParseFieldSpecs(v interface{}) ([]FieldSpec, error) {
+	t := reflect.TypeOf(v)
+	if t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("provided value is not a struct")
+	}
+
+	specs := make([]FieldSpec, 0, t.NumField())
+	var errs []error
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		key := field.Name
+		if name, _, _ := strings.Cut(field.Tag.Get("json"), ","); name == "-" {
+			continue
+		} else if name != "" {
+			key = name
+		}
+
+		spec, err := parseFieldSpec(field.Tag.Get("tg"), FieldSpec{
+			Key:   key,
+			Name:  field.Name,
+			Index: field.Index,
+			Type:  field.Type,
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("field %s: %w", field.Name, err))
+			continue
+		}
+		specs = append(specs, spec)
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	sort.SliceStable(specs, func(i, j int) bool {
+		return specs[i].Order < specs[j].Order
+	})
+
+	return specs, nil
+}
+
+func parseFieldSpec(tag string, spec FieldSpec) (FieldSpec, error) {
+	entries, err := parseTag(tag)
+	if err != nil {
+		return spec, err
+	}
+
+	for _, entry := range entries {
+		key, value := entry[0], entry[1]
+
+		switch {
+		case flagTags[key]:
+			on, err := parseFlag(key, value)
+			if err != nil {
+				return spec, err
+			}
+			switch key {
+			case TagRequired:
+				spec.Required = on
+			case TagSecret:
+				spec.Secret = on
+			case TagReadOnly:
+				spec.ReadOnly = on
+			case TagNoEdit:
+				spec.NoEdit = on
+			}
+			continue
+		case !valueTags[key]:
+			return spec, fmt.Errorf("%w: unknown key %q", ErrInvalidTag, key)
+		case value == "":
+			return spec, fmt.Errorf("%w: %s requires a value", ErrInvalidTag, key)
+		}
+
+		switch key {
+		case TagLabel:
+			spec.Label = value
+		case TagOrder:
+			order, err := strconv.Atoi(value)
+			if err != nil {
+				return spec, fmt.Errorf("%w: order %q is not a whole number", ErrInvalidTag, value)
+			}
+			spec.Order = order
+		case TagMin, TagMax:
+			n, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return spec, fmt.Errorf("%w: %s %q is not a number", ErrInvalidTag, key, value)
+			}
+			if key == TagMin {
+				spec.Min = &n
+			} else {
+				spec.Max = &n
+			}
+		case TagRegex:
+			regex, err := regexp.Compile(value)
+			if err != nil {
+				return spec, fmt.Errorf("%w: regex: %s", ErrInvalidTag, err)
+			}
+			spec.Regex = regex
+		case TagChoices:
+			for _, choice := range strings.Split(value, choicesSeparator) {
+				if choice = strings.TrimSpace(choice); choice != "" {
+					spec.Choices = append(spec.Choices, choice)
+				}
+			}
+		case TagFormat:
+			spec.Format = value
+		case TagHelp:
+			spec.Help = value
+		case TagGroup:
+			spec.Group = strings.TrimSpace(value)
+		}
+	}
+
+	if len(spec.Choices) == 0 {
+		spec.Choices = enumValues(spec.Type)
+	}
+
+	if spec.Min != nil && spec.Max != nil && *spec.Min > *spec.Max {
+		return spec, fmt.Errorf("%w: min %v is greater than max %v", ErrInvalidTag, *spec.Min, *spec.Max)
+	}
+
+	return spec, nil
+}
+
+func parseFlag(key, value string) (bool, error) {
+	switch value {
+	case "", "true":
+		return true, nil
+	case "false":
+		return false, nil
+	}
+	return false, fmt.Errorf("%w: %s takes no value or true/false, got %q", ErrInvalidTag, key, value)
+}
+
+// parseTag splits a tg tag into key and value pairs.
+// A value containing an unescaped ":" is an error, it would be cut off otherwise.
+func parseTag(tag string) ([][2]string, error) {
+	var entries [][2]string
+
+	for _, entry := range splitEscaped(tag, ';') {
+		parts := splitEscaped(entry, ':')
+		key := strings.TrimSpace(unescape(parts[0]))
+		if key == "" {
+			if strings.TrimSpace(entry) == "" {
+				continue
+			}
+			return nil, fmt.Errorf("%w: %q has no key", ErrInvalidTag, entry)
+		}
+		if len(parts) > 2 {
+			return nil, fmt.Errorf("%w: value of %s contains \":\", escape it as \"\\:\"", ErrInvalidTag, key)
+		}
+
+		value := ""
+		if len(parts) == 2 {
+			value = strings.TrimSpace(unescape(parts[1]))
+		}
+		entries = append(entries, [2]string{key, value})
+	}
+
+	return entries, nil
+}
+
+// splitEscaped splits s at the separators not escaped by a backslash, the escapes are kept
+func splitEscaped(s string, sep byte) []string {
+	var parts []string
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case sep:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// unescape removes the backslashes of escaped separators, other escapes such as \d in a regex are kept
+func unescape(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) && strings.IndexByte(`:;\`, s[i+1]) >= 0 {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// DisplayLabel returns the label, or the key if no label is set
+func (spec FieldSpec) DisplayLabel() string {
+	if spec.Label != "" {
+		return spec.Label
+	}
+	return spec.Key
+}
+
+/*
+Parse parses the answer of a user into a value of the field type, using the format tag as time layout of a time.Time.
+An empty answer is the zero value of the field type. The errors of a secret field don't quote the answer.
+*/
+func (spec FieldSpec) Parse(answer string) (reflect.Value, error) {
+	value, err := spec.parse(answer)
+	if err != nil && spec.Secret {
+		return reflect.Value{}, fmt.Errorf("%s is not a valid %s", spec.DisplayLabel(), spec.Type)
+	}
+	return value, err
+}
+
+// parse parses the answer into a value of the field type
+func (spec FieldSpec) parse(answer string) (reflect.Value, error) {
+	// pointers, strings and slices parse an empty answer themselves
+	if kind := spec.Type.Kind(); kind != reflect.Ptr && kind != reflect.String && kind != reflect.Slice && strings.TrimSpace(answer) == "" {
+		return reflect.Zero(spec.Type), nil
+	}
+
+	t := spec.Type
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t != timeType || spec.Format == "" || strings.TrimSpace(answer) == "" {
+		return ParseValue(answer, spec.Type)
+	}
+
+	date, err := time.ParseInLocation(spec.Format, strings.TrimSpace(answer), time.Local)
+	if err != nil {
+		return reflect.Value{}, fmt.Errorf("%q is not a date, e.g. %s", answer, spec.Format)
+	}
+	if spec.Type.Kind() == reflect.Ptr {
+		return reflect.ValueOf(&date), nil
+	}
+	return reflect.ValueOf(date), nil
+}
+
+/*
+Validate checks the answer of a user against the type and the required, min, max, regex and choices tags.
+Min and max bound the value of numbers and the length of other values.
+An empty answer is only rejected by the required tag, otherwise it keeps the zero value.
+*/
+func (spec FieldSpec) Validate(answer string) error {
+	answer = strings.TrimSpace(answer)
+	if answer == "" {
+		if spec.Required {
+			return fmt.Errorf("%s is required", spec.DisplayLabel())
+		}
+		return nil
+	}
+
+	value, err := spec.Parse(answer)
+	if err != nil {
+		return err
+	}
+
+	// the items of a slice are checked one by one
+	items := []string{answer}
+	if value.Kind() == reflect.Slice {
+		items = SplitList(answer)
+	}
+	for _, item := range items {
+		if len(spec.Choices) > 0 && !contains(spec.Choices, item) {
+			return fmt.Errorf("%s must be one of %s", spec.DisplayLabel(), strings.Join(spec.Choices, ", "))
+		}
+		if spec.Regex != nil && !spec.Regex.MatchString(item) {
+			return fmt.Errorf("%s has an invalid format", spec.DisplayLabel())
+		}
+	}
+
+	n, isNumber := number(value)
+	what := "be"
+	switch {
+	case value.Kind() == reflect.Slice:
+		n, what = float64(len(items)), "have a number of items of"
+	case !isNumber:
+		n, what = float64(utf8.RuneCountInString(answer)), "have a length of"
+	}
+	if spec.Min != nil && n < *spec.Min {
+		return fmt.Errorf("%s must %s at least %v", spec.DisplayLabel(), what, *spec.Min)
+	}
+	if spec.Max != nil && n > *spec.Max {
+		return fmt.Errorf("%s must %s at most %v", spec.DisplayLabel(), what, *spec.Max)
+	}
+
+	return nil
+}
+
+/*
+FormatValue formats a value of the field for display, using the format tag.
+*/
+func (spec FieldSpec) FormatValue(value interface{}) string {
+	v := reflect.ValueOf(value)
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return ""
+		}
+		value = v.Elem().Interface()
+	}
+
+	if date, ok := value.(time.Time); ok {
+		if date.IsZero() {
+			return ""
+		}
+		if spec.Format != "" {
+			return date.Format(spec.Format)
+		}
+		return date.Format(DateFormat)
+	}
+	if spec.Format != "" {
+		return fmt.Sprintf(spec.Format, value)
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+// enumValues returns the values of an Enum type, of a pointer to it or of a slice of it
+func enumValues(t reflect.Type) []string {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	if enum, ok := reflect.New(t).Interface().(Enum); ok {
+		return enum.EnumValues()
+	}
+	return nil
+}
+
+// number returns a number value as float64
+func number(v reflect.Value) (float64, bool) {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return 0, false
+		}
+		v = v.Elem()
+	}
+	if v.Type() == durationType {
+		return 0, false
+	}
+
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	}
+	return 0, false
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,122 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type profile struct {
+	Name    string    `json:"name" tg:"label:Full name;required;max:5;order:2"`
+	Age     int       `json:"age" tg:"min:18;max:99;order:1;regex:^\\d+$"`
+	Email   string    `json:"email" tg:"regex:^[^@]+@[^@]+$;help:We never share it"`
+	Role    string    `json:"role" tg:"choices:admin | editor|viewer"`
+	Start   time.Time `json:"start" tg:"format:15\\:04"`
+	Token   string    `json:"token" tg:"secret;readonly"`
+	ID      int       `json:"id" tg:"noedit:false"`
+	Ignored string    `json:"-" tg:"unknown"`
+	hidden  string
+}
+
+func TestParseFieldSpecs(t *testing.T) {
+	specs, err := ParseFieldSpecs(&profile{})
+	assert.NoError(t, err)
+
+	var keys []string
+	byKey := map[string]FieldSpec{}
+	for _, spec := range specs {
+		keys = append(keys, spec.Key)
+		byKey[spec.Key] = spec
+	}
+	assert.Equal(t, []string{"email", "role", "start", "token", "id", "age", "name"}, keys, "sorted by order, then declaration order")
+
+	name := byKey["name"]
+	assert.Equal(t, "Full name", name.DisplayLabel())
+	assert.True(t, name.Required)
+	assert.Equal(t, 5.0, *name.Max)
+	assert.Equal(t, "email", byKey["email"].DisplayLabel())
+	assert.Equal(t, "We never share it", byKey["email"].Help)
+	assert.Equal(t, []string{"admin", "editor", "viewer"}, byKey["role"].Choices)
+	assert.Equal(t, "15:04", byKey["start"].Format, "escaped colon")
+	assert.True(t, byKey["token"].Secret)
+	assert.True(t, byKey["token"].ReadOnly)
+	assert.False(t, byKey["id"].NoEdit)
+}
+
+func TestParseFieldSpecsErrors(t *testing.T) {
+	tests := map[string]any{
+		"unescaped colon": struct {
+			Start string `tg:"format:15:04"`
+		}{},
+		"unknown key": struct {
+			Name string `tg:"lable:Name"`
+		}{},
+		"missing value": struct {
+			Name string `tg:"label"`
+		}{},
+		"flag value": struct {
+			Name string `tg:"required:yes"`
+		}{},
+		"number": struct {
+			Age int `tg:"min:ten"`
+		}{},
+		"regex": struct {
+			Name string `tg:"regex:[a-"`
+		}{},
+		"min over max": struct {
+			Age int `tg:"min:10;max:1"`
+		}{},
+	}
+
+	for name, v := range tests {
+		_, err := ParseFieldSpecs(v)
+		assert.ErrorIs(t, err, ErrInvalidTag, name)
+
+		_, err = ParseTGTags(v)
+		if name == "unescaped colon" {
+			assert.ErrorIs(t, err, ErrInvalidTag, "ParseTGTags rejects malformed tags too")
+		}
+	}
+}
+
+func TestFieldSpecValidate(t *testing.T) {
+	specs, err := ParseFieldSpecs(profile{})
+	assert.NoError(t, err)
+	byKey := map[string]FieldSpec{}
+	for _, spec := range specs {
+		byKey[spec.Key] = spec
+	}
+
+	assert.Error(t, byKey["name"].Validate(" "), "required")
+	assert.Error(t, byKey["name"].Validate("Johnny"), "max length")
+	assert.NoError(t, byKey["name"].Validate("John"))
+	assert.Error(t, byKey["age"].Validate("17"))
+	assert.Error(t, byKey["age"].Validate("old"))
+	assert.NoError(t, byKey["age"].Validate("42"))
+	assert.Error(t, byKey["email"].Validate("nobody"))
+	assert.NoError(t, byKey["email"].Validate(""), "not required")
+	assert.NoError(t, byKey["age"].Validate(" "), "an optional number may be left empty")
+	age, err := byKey["age"].Parse("")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, age.Interface(), "an empty answer keeps the zero value")
+	assert.Error(t, FieldSpec{Key: "count", Type: reflect.TypeOf(0), Required: true}.Validate(""))
+	assert.Error(t, byKey["role"].Validate("owner"))
+	assert.NoError(t, byKey["role"].Validate("editor"))
+	assert.NoError(t, byKey["start"].Validate("09:30"))
+
+	tags := FieldSpec{Key: "tags", Type: reflect.TypeOf([]string{}), Choices: []string{"a", "b"}}
+	assert.NoError(t, tags.Validate("a, b"))
+	assert.Error(t, tags.Validate("a, c"), "each item is one of the choices")
+	list, err := tags.Parse("a, b")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, list.Interface())
+
+	start, err := byKey["start"].Parse("09:30")
+	assert.NoError(t, err)
+	assert.Equal(t, "09:30", byKey["start"].FormatValue(start.Interface()))
+
+	pin := FieldSpec{Key: "pin", Type: reflect.TypeOf(0), Secret: true}
+	assert.EqualError(t, pin.Validate("12a4"), "pin is not a valid int", "the answer is not quoted")
+}
@@ -0,0 +1,164 @@
+package parser
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DateFormat is the format of time.Time values, which ParseValue also accepts in RFC 3339
+const DateFormat = "2006-01-02"
+
+var (
+	timeType            = reflect.TypeOf(time.Time{})
+	durationType        = reflect.TypeOf(time.Duration(0))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// ParseValue parses the text input of a user into a value of type t.
+// Strings, bools, ints, uints, floats, time.Time, time.Duration, pointers to them,
+// types implementing encoding.TextUnmarshaler and slices of them, as a comma separated list, are supported.
+func ParseValue(s string, t reflect.Type) (reflect.Value, error) {
+	s = strings.TrimSpace(s)
+
+	if t.Kind() == reflect.Ptr {
+		if s == "" {
+			return reflect.Zero(t), nil
+		}
+		elem, err := ParseValue(s, t.Elem())
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		ptr := reflect.New(t.Elem())
+		ptr.Elem().Set(elem)
+		return ptr, nil
+	}
+
+	v := reflect.New(t).Elem()
+
+	switch {
+	case t == timeType:
+		date, err := parseTime(s)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		v.Set(reflect.ValueOf(date))
+		return v, nil
+	case t == durationType:
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("%q is not a duration, e.g. 1h30m", s)
+		}
+		v.SetInt(int64(d))
+		return v, nil
+	case reflect.PointerTo(t).Implements(textUnmarshalerType):
+		if err := v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s)); err != nil {
+			return reflect.Value{}, err
+		}
+		return v, nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := parseBool(s)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, t.Bits())
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("%q is not a whole number", s)
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, t.Bits())
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("%q is not a positive whole number", s)
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(strings.Replace(s, ",", ".", 1), t.Bits())
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("%q is not a number", s)
+		}
+		v.SetFloat(n)
+	case reflect.Slice:
+		for _, item := range SplitList(s) {
+			elem, err := ParseValue(item, t.Elem())
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			v = reflect.Append(v, elem)
+		}
+	default:
+		return reflect.Value{}, fmt.Errorf("unsupported type %s", t)
+	}
+
+	return v, nil
+}
+
+// CanParse reports whether ParseValue supports the type t
+func CanParse(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == timeType || t == durationType || reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return true
+	}
+
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	case reflect.Slice:
+		return t.Elem().Kind() != reflect.Slice && CanParse(t.Elem())
+	}
+	return false
+}
+
+// listSeparator separates the items of a slice in the text input
+const listSeparator = ","
+
+// SplitList splits a comma separated list, empty items are left out.
+func SplitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, listSeparator) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseBool accepts the answers of a user besides the strconv.ParseBool values
+func parseBool(s string) (bool, error) {
+	switch strings.ToLower(s) {
+	case "yes", "y", "on":
+		return true, nil
+	case "no", "n", "off":
+		return false, nil
+	}
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return false, fmt.Errorf("%q is not yes or no", s)
+	}
+	return b, nil
+}
+
+func parseTime(s string) (time.Time, error) {
+	if date, err := time.ParseInLocation(DateFormat, s, time.Local); err == nil {
+		return date, nil
+	}
+	if date, err := time.Parse(time.RFC3339, s); err == nil {
+		return date, nil
+	}
+	return time.Time{}, fmt.Errorf("%q is not a date, e.g. %s", s, DateFormat)
+}
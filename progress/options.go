@@ -1,5 +1,10 @@
 package progress
 
+import (
+	"github.com/jkevinp/tgui/callbackdata"
+	"github.com/jkevinp/tgui/router"
+)
+
 type Option func(p *Progress)
 
 // WithRenderTextFunc sets the render text function.
@@ -38,3 +43,18 @@ func WithPrefix(s string) Option {
 		w.prefix = s
 	}
 }
+
+// WithRouter dispatches the widget's callbacks through the router instead of registering its own bot handler.
+// The router assigns the prefix, so WithPrefix has no effect.
+func WithRouter(r *router.Router) Option {
+	return func(p *Progress) {
+		p.router = r
+	}
+}
+
+// WithSigningKey signs the callback data of the cancel button with the key, clicks with tampered data are rejected.
+func WithSigningKey(key []byte) Option {
+	return func(p *Progress) {
+		p.signer = callbackdata.NewSigner(key)
+	}
+}
@@ -7,6 +7,8 @@ import (
 
 	"github.com/go-telegram/bot"
 	"github.com/go-telegram/bot/models"
+	"github.com/jkevinp/tgui/callbackdata"
+	"github.com/jkevinp/tgui/router"
 )
 
 type OnCancelFunc func(ctx context.Context, b *bot.Bot, message models.MaybeInaccessibleMessage)
@@ -26,6 +28,8 @@ type Progress struct {
 	onCancelHandlerId string
 	deleteOnCancel    bool
 	canceled          bool
+	router            *router.Router
+	signer            *callbackdata.Signer
 }
 
 func New(b *bot.Bot, opts ...Option) *Progress {
@@ -41,8 +45,13 @@ func New(b *bot.Bot, opts ...Option) *Progress {
 	}
 
 	if p.onCancel != nil {
-		p.onCancelHandlerId = b.RegisterHandler(bot.HandlerTypeCallbackQueryData, p.prefix,
-			bot.MatchTypeExact, p.onCancelCall)
+		if p.router != nil {
+			p.onCancelHandlerId, p.prefix = p.router.Handle("pr", p.onCancelCall)
+		} else {
+			// the signature of the cancel button is fixed, so an exact match rejects tampered data
+			p.onCancelHandlerId = b.RegisterHandler(bot.HandlerTypeCallbackQueryData, p.signer.Sign(p.prefix),
+				bot.MatchTypeExact, p.onCancelCall)
+		}
 	}
 
 	return p
@@ -88,7 +97,11 @@ func (p *Progress) Delete(ctx context.Context, b *bot.Bot) {
 // ctx is being passed for forward compatibility reasons
 func (p *Progress) Done(ctx context.Context, b *bot.Bot) {
 	if p.onCancelHandlerId != "" {
-		b.UnregisterHandler(p.onCancelHandlerId)
+		if p.router != nil {
+			p.router.Remove(p.onCancelHandlerId)
+		} else {
+			b.UnregisterHandler(p.onCancelHandlerId)
+		}
 		p.onCancelHandlerId = ""
 	}
 }
@@ -105,13 +118,26 @@ func (p *Progress) buildKeyboard() *models.InlineKeyboardMarkup {
 	return &models.InlineKeyboardMarkup{
 		InlineKeyboard: [][]models.InlineKeyboardButton{
 			{
-				{Text: p.cancelText, CallbackData: p.prefix},
+				{Text: p.cancelText, CallbackData: p.signer.Sign(p.prefix)},
 			},
 		},
 	}
 }
 
 func (p *Progress) onCancelCall(ctx context.Context, b *bot.Bot, update *models.Update) {
+	if data, err := p.signer.Verify(update.CallbackQuery.Data); err != nil || data != p.prefix {
+		p.onError(fmt.Errorf("wrong callback data, %s", update.CallbackQuery.Data))
+		if !router.Answer(ctx, callbackdata.InvalidButtonText) {
+			if _, err := b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+				CallbackQueryID: update.CallbackQuery.ID,
+				Text:            callbackdata.InvalidButtonText,
+			}); err != nil {
+				p.onError(err)
+			}
+		}
+		return
+	}
+
 	p.canceled = true
 	if p.deleteOnCancel {
 		_, err := b.DeleteMessage(ctx, &bot.DeleteMessageParams{
@@ -0,0 +1,192 @@
+package questionaire
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/jkevinp/tgui/button"
+	"github.com/jkevinp/tgui/callbackdata"
+	"github.com/jkevinp/tgui/router"
+)
+
+// Commands encoded in the callback data after the questionnaire prefix.
+// Choices are sent by their index in Question.Choices, so the choice data never has to fit into the callback data.
+const (
+	cbCmdSelect   = "sel"    // Followed by "_<step>_<choice index>"
+	cbCmdUnselect = "unsel"  // Followed by "_<step>_<choice index>"
+	cbCmdDone     = "done"   // Followed by "_<step>", finishes a checkbox question
+	cbCmdEdit     = "edit"   // Followed by "_<step>", goes back to an answered question
+	cbCmdCancel   = "cancel" // Cancels the questionnaire
+)
+
+// SetRouter dispatches the questionnaire's callbacks through the router instead of registering its own bot handler.
+func (q *Questionaire) SetRouter(r *router.Router) *Questionaire {
+	q.router = r
+	return q
+}
+
+// SetSigningKey signs the callback data with the key, clicks with tampered data are rejected.
+func (q *Questionaire) SetSigningKey(key []byte) *Questionaire {
+	q.signer = callbackdata.NewSigner(key)
+	return q
+}
+
+// callbackPrefix is the prefix of every button of the questionnaire.
+// A routed questionnaire uses the prefix assigned by the router.
+func (q *Questionaire) callbackPrefix() string {
+	if q.routePrefix != "" {
+		return q.routePrefix
+	}
+	return q.callbackID + "_"
+}
+
+// register adds the questionnaire's callback handler to the bot, once for all questions and summaries.
+func (q *Questionaire) register(b *bot.Bot) {
+	if q.callbackHandlerID == "" && q.router != nil {
+		q.callbackHandlerID, q.routePrefix = q.router.Handle("qs", q.callback)
+	} else if q.callbackHandlerID == "" {
+		q.callbackHandlerID = b.RegisterHandler(bot.HandlerTypeCallbackQueryData, q.callbackPrefix(), bot.MatchTypePrefix, q.callback)
+	}
+}
+
+// unregister removes the questionnaire's callback handler from the router or the bot.
+func (q *Questionaire) unregister(b *bot.Bot) {
+	if q.callbackHandlerID != "" && q.router != nil {
+		q.router.Remove(q.callbackHandlerID)
+	} else if q.callbackHandlerID != "" {
+		b.UnregisterHandler(q.callbackHandlerID)
+	}
+	q.callbackHandlerID = ""
+	q.routePrefix = ""
+}
+
+// button creates a button sending the command to the questionnaire.
+func (q *Questionaire) button(text string, command string, args ...int) models.InlineKeyboardButton {
+	data := q.callbackPrefix() + command
+	for _, arg := range args {
+		data += "_" + strconv.Itoa(arg)
+	}
+	return models.InlineKeyboardButton{Text: text, CallbackData: q.signer.Sign(data)}
+}
+
+// choiceIndex returns the position of the choice in Choices, counted across rows.
+func (q *Question) choiceIndex(callbackData string) int {
+	index := 0
+	for _, row := range q.Choices {
+		for _, choice := range row {
+			if choice.CallbackData == callbackData {
+				return index
+			}
+			index++
+		}
+	}
+	return -1
+}
+
+// choiceAt returns the choice at the position returned by choiceIndex.
+func (q *Question) choiceAt(index int) (button.Button, bool) {
+	if index < 0 {
+		return button.Button{}, false
+	}
+	for _, row := range q.Choices {
+		if index < len(row) {
+			return row[index], true
+		}
+		index -= len(row)
+	}
+	return button.Button{}, false
+}
+
+// callback receives every button click of the questionnaire.
+// Buttons of questions other than the current one are ignored, e.g. a double tap on a deleted keyboard.
+func (q *Questionaire) callback(ctx context.Context, b *bot.Bot, update *models.Update) {
+	data, err := q.signer.Verify(update.CallbackQuery.Data)
+	if err != nil {
+		fmt.Println("[Questionaire.callback]", q.callbackID, err)
+		q.callbackAnswer(ctx, b, update.CallbackQuery, callbackdata.InvalidButtonText)
+		return
+	}
+
+	mes := update.CallbackQuery.Message
+	command, args, _ := strings.Cut(strings.TrimPrefix(data, q.callbackPrefix()), "_")
+	fmt.Println("[Questionaire.callback]", q.callbackID, "->", command, args)
+
+	if command == cbCmdEdit {
+		if step, err := strconv.Atoi(args); err != nil || step < 0 || step >= len(q.questions) {
+			fmt.Println("[Questionaire.callback] invalid step:", args)
+			q.callbackAnswer(ctx, b, update.CallbackQuery, callbackdata.InvalidButtonText)
+			return
+		}
+	}
+	q.callbackAnswer(ctx, b, update.CallbackQuery, "")
+
+	switch command {
+	case cbCmdCancel:
+		q.deleteMessage(ctx, b, mes)
+		q.onCancel(ctx, b, mes, nil)
+		return
+	case cbCmdEdit:
+		q.onBack(ctx, b, mes, []byte(args))
+		return
+	}
+
+	stepStr, indexStr, _ := strings.Cut(args, "_")
+	if step, err := strconv.Atoi(stepStr); err != nil || step != q.currentQuestionIndex || step >= len(q.questions) {
+		fmt.Println("[Questionaire.callback] ignoring button of step:", stepStr)
+		return
+	}
+	curQuestion := q.questions[q.currentQuestionIndex]
+
+	if command == cbCmdDone {
+		q.onDoneChoosing(ctx, b, mes, nil)
+		return
+	}
+	if command != cbCmdSelect && command != cbCmdUnselect {
+		fmt.Println("[Questionaire.callback] unknown command:", command)
+		return
+	}
+
+	index, err := strconv.Atoi(indexStr)
+	if err != nil {
+		return
+	}
+	choice, ok := curQuestion.choiceAt(index)
+	if !ok {
+		fmt.Println("[Questionaire.callback] unknown choice:", indexStr)
+		return
+	}
+
+	q.deleteMessage(ctx, b, mes)
+	switch command {
+	case cbCmdSelect:
+		q.onInlineKeyboardSelect(ctx, b, mes, []byte(choice.CallbackData))
+	case cbCmdUnselect:
+		q.onInlineKeyboardUnSelect(ctx, b, mes, []byte(choice.CallbackData))
+	}
+}
+
+// callbackAnswer answers the callback query, through the router for a routed questionnaire.
+func (q *Questionaire) callbackAnswer(ctx context.Context, b *bot.Bot, callbackQuery *models.CallbackQuery, text string) {
+	if router.Answer(ctx, text) {
+		return
+	}
+	b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+		CallbackQueryID: callbackQuery.ID,
+		Text:            text,
+	})
+}
+
+// deleteMessage deletes the clicked message, its question is sent again after the click.
+func (q *Questionaire) deleteMessage(ctx context.Context, b *bot.Bot, mes models.MaybeInaccessibleMessage) {
+	if mes.Message == nil {
+		return
+	}
+	b.DeleteMessage(ctx, &bot.DeleteMessageParams{
+		ChatID:    q.chatID,
+		MessageID: mes.Message.ID,
+	})
+}
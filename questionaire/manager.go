@@ -18,6 +18,15 @@ import (
 type Manager struct {
 	mutex         sync.RWMutex            // Protects concurrent access to conversations map
 	conversations map[int64]*Questionaire // Maps chat IDs to active questionnaire instances
+	focus         map[int64]focusHandler  // Maps chat IDs to the widget receiving text outside questionnaires
+}
+
+// TextHandler receives text messages for a chat without an active questionnaire.
+type TextHandler func(ctx context.Context, b *bot.Bot, update *models.Update)
+
+type focusHandler struct {
+	owner   string
+	handler TextHandler
 }
 
 // NewManager creates a new Manager instance for handling questionnaire sessions.
@@ -31,6 +40,7 @@ type Manager struct {
 func NewManager() *Manager {
 	return &Manager{
 		conversations: make(map[int64]*Questionaire),
+		focus:         make(map[int64]focusHandler),
 	}
 }
 
@@ -71,6 +81,32 @@ func (m *Manager) Exists(chatID int64) bool {
 	return exists
 }
 
+// SetFocus routes text messages of the chat to the handler while no questionnaire is active in it.
+// The owner identifies the widget holding the focus, usually its prefix.
+// A later call for the same chat replaces the previous focus.
+func (m *Manager) SetFocus(chatID int64, owner string, handler TextHandler) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.focus[chatID] = focusHandler{owner: owner, handler: handler}
+}
+
+// RemoveFocus removes the focus of the chat if it is still held by the owner.
+func (m *Manager) RemoveFocus(chatID int64, owner string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if f, ok := m.focus[chatID]; ok && f.owner == owner {
+		delete(m.focus, chatID)
+	}
+}
+
+// HasFocus reports whether the owner holds the focus of the chat.
+func (m *Manager) HasFocus(chatID int64, owner string) bool {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	f, ok := m.focus[chatID]
+	return ok && f.owner == owner
+}
+
 // HandleMessage processes incoming text messages for active questionnaire conversations.
 // This method should be registered with your bot to handle text message updates:
 //
@@ -79,8 +115,9 @@ func (m *Manager) Exists(chatID int64) bool {
 // The method:
 //   - Checks if an active questionnaire exists for the message's chat ID
 //   - Routes the message text to the appropriate questionnaire's Answer method
+//   - Deletes the message at once if it answers a secret question
 //   - Handles questionnaire completion and cleanup automatically
-//   - Ignores messages from chats without active questionnaires
+//   - Passes messages from chats without active questionnaires to the focused widget, if any
 //
 // This enables seamless text input handling for questionnaire text questions
 // without requiring manual message routing in your application code.
@@ -93,19 +130,41 @@ func (m *Manager) HandleMessage(ctx context.Context, b *bot.Bot, update *models.
 
 	q, exists := m.conversations[chatID]
 	if !exists {
+		m.mutex.RLock()
+		f, focused := m.focus[chatID]
+		m.mutex.RUnlock()
+		if focused {
+			f.handler(ctx, b, update)
+		}
 		return
 	}
 
+	text := update.Message.Text
+	if q.isSecret() {
+		text = SecretAnswerText
+	}
 	fmt.Printf("[questionaire manager] ChatID: %v, Message: %v, Active conversations: %d\n",
-		chatID, update.Message.Text, len(m.conversations))
+		chatID, text, len(m.conversations))
+
+	switch {
+	case q.isSecret():
+		// the secret must not stay in the chat history
+		if _, err := b.DeleteMessage(ctx, &bot.DeleteMessageParams{
+			ChatID:    chatID,
+			MessageID: update.Message.ID,
+		}); err != nil {
+			fmt.Println("[questionaire manager] error deleting secret answer:", err)
+		}
+	case q.deleteAnswers:
+		q.msgIds = append(q.msgIds, update.Message.ID)
+	}
 
 	if isDone := q.Answer(ctx, update.Message.Text, b, chatID); isDone {
-		result, err := GetResultByte(q)
-		if err != nil {
+		if _, err := GetResultByte(q); err != nil {
 			fmt.Println("[questionaire manager] error getting result:", err)
 			return
 		}
-		fmt.Println("[questionaire manager] result of questionaire:", string(result))
+		fmt.Println("[questionaire manager] result of questionaire:", maskedResult(q))
 
 		q.Done(ctx, b, update)
 
@@ -21,12 +21,15 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"reflect"
 	"strconv"
 
 	"github.com/go-telegram/bot/models"
 	"github.com/jkevinp/tgui/button" // ButtonGrid for organized choice layouts
+	"github.com/jkevinp/tgui/callbackdata"
 	"github.com/jkevinp/tgui/helper"
-	"github.com/jkevinp/tgui/keyboard/inline"
+	"github.com/jkevinp/tgui/parser"
+	"github.com/jkevinp/tgui/router"
 
 	"github.com/go-telegram/bot"
 	"github.com/sentimensrg/ctx/mergectx"
@@ -66,6 +69,10 @@ const (
 	DoneButtonText = "✅ Done"
 	// CancelButtonText is the text displayed on cancel buttons.
 	CancelButtonText = "❌ Cancel"
+	// SecretAnswerText is displayed instead of the answer of a secret question.
+	SecretAnswerText = "••••••"
+	// ListHintText is appended to the text of a question answered with a comma separated list.
+	ListHintText = "Separate multiple answers with commas."
 )
 
 // Questionaire represents an interactive questionnaire session for a specific chat.
@@ -78,8 +85,12 @@ type Questionaire struct {
 	onDoneHandler        onDoneHandlerFunc // Function called when all questions are completed
 	onCancelHandler      func()            // Function called when questionnaire is cancelled
 
-	callbackID string // Unique identifier for this questionnaire's callback handlers
-	msgIds     []int  // Message IDs of sent questionnaire messages for cleanup
+	callbackID        string               // Unique identifier for this questionnaire's callback handler
+	callbackHandlerID string               // ID of the callback handler registered with the bot, empty until shown
+	router            *router.Router       // Optional router dispatching the callbacks, see SetRouter
+	routePrefix       string               // Prefix assigned by the router, see callbackPrefix
+	signer            *callbackdata.Signer // Optional signer of the callback data, see SetSigningKey
+	msgIds            []int                // Message IDs of sent questionnaire messages for cleanup
 
 	chatID any // Telegram chat ID where this questionnaire is running
 
@@ -91,6 +102,13 @@ type Questionaire struct {
 	manager *Manager
 	// allowEditAnswers controls whether answered questions can be edited (default: true)
 	allowEditAnswers bool
+	// deleteAnswers deletes the text messages of the user with the questionnaire messages (default: false)
+	deleteAnswers bool
+
+	// target is a copy of the struct given to NewFromStruct, the answers are decoded into a copy of it
+	target reflect.Value
+	// fieldSpecs are the specs of the struct fields asked, see NewFromStruct
+	fieldSpecs []parser.FieldSpec
 }
 
 // GetAnswers returns a map of question keys to their answers or selected choices.
@@ -142,6 +160,9 @@ type Question struct {
 	QuestionFormat QuestionFormat
 	// MsgID stores the Telegram message ID of the question message for editing
 	MsgID int
+	// Secret masks the answer in summaries and logs and deletes the message of the user at once,
+	// set by AddSecretQuestion and the secret tag of AddFieldQuestion
+	Secret bool
 }
 
 // SetMsgID sets the Telegram message ID for this question.
@@ -167,11 +188,12 @@ func (q *Questionaire) sendAnswerSummary(ctx context.Context, b *bot.Bot, questi
 	displayAnswer := question.GetDisplayAnswer()
 
 	// Check if editing is allowed
-	var editKB *inline.Keyboard
+	var editKB *models.InlineKeyboardMarkup
 	if q.allowEditAnswers {
-		editKB = inline.New(b, inline.WithPrefix(
-			fmt.Sprintf("qs_%s_answer_%d", q.callbackID, questionIndex),
-		)).Button(helper.EscapeTelegramReserved(EditButtonText), []byte(fmt.Sprintf("%d", questionIndex)), q.onBack)
+		q.register(b)
+		editKB = &models.InlineKeyboardMarkup{InlineKeyboard: [][]models.InlineKeyboardButton{{
+			q.button(helper.EscapeTelegramReserved(EditButtonText), cbCmdEdit, questionIndex),
+		}}}
 
 		if question.QuestionFormat == QuestionFormatText && question.MsgID != 0 {
 			// For text questions, edit the existing message to add edit button (if enabled)
@@ -203,7 +225,7 @@ sendNewAnswerSummary sends a new message with answer summary and edit button (if
 Used for radio/checkbox questions or as fallback when editing fails.
 editKB can be nil when editing is disabled.
 */
-func (q *Questionaire) sendNewAnswerSummary(ctx context.Context, b *bot.Bot, question *Question, displayAnswer string, editKB *inline.Keyboard) {
+func (q *Questionaire) sendNewAnswerSummary(ctx context.Context, b *bot.Bot, question *Question, displayAnswer string, editKB *models.InlineKeyboardMarkup) {
 	answerText := fmt.Sprintf("✅ *%s*\n%s",
 		helper.EscapeTelegramReserved(question.Text),
 		helper.EscapeTelegramReserved(displayAnswer))
@@ -244,6 +266,9 @@ func (q *Question) GetDisplayAnswer() string {
 		if q.Answer == "" {
 			return "Not answered"
 		}
+		if q.Secret {
+			return SecretAnswerText
+		}
 		return q.Answer
 
 	case QuestionFormatRadio:
@@ -258,6 +283,9 @@ func (q *Question) GetDisplayAnswer() string {
 				}
 			}
 		}
+		if q.Secret {
+			return SecretAnswerText // A typed answer
+		}
 		return q.Answer // Fallback to callback data if not found
 
 	case QuestionFormatCheck:
@@ -364,6 +392,9 @@ func (q *Question) Validate(answer string) error {
 	if q.validator != nil {
 
 		result := q.validator(answer)
+		if q.Secret {
+			answer = SecretAnswerText
+		}
 		fmt.Println("[Question] Validating answer:", answer, "for question:", q.Key, "result:", result)
 		return result
 	}
@@ -495,6 +526,90 @@ func (q *Questionaire) AddQuestion(key string, text string, choices [][]button.B
 	return q
 }
 
+// AddSecretQuestion adds a question like AddQuestion whose answer is a secret, like a password or an API key.
+//
+// The answer is masked in the answer summary and in logs, and the message the user answered with
+// is deleted as soon as it is read. The answer is passed to the onDoneHandler unmasked.
+//
+// Example:
+//
+//	q.AddSecretQuestion("api_key", "Paste your API key:", nil, validateAPIKey)
+func (q *Questionaire) AddSecretQuestion(key string, text string, choices [][]button.Button, validateFunc func(answer string) error) *Questionaire {
+	q.AddQuestion(key, text, choices, validateFunc)
+	q.questions[len(q.questions)-1].Secret = true
+	return q
+}
+
+// AddFieldQuestion adds a question for a struct field described by its tg tags.
+//
+// The question text is the label followed by the help tag, the choices tag adds the choices
+// and the answer is validated with FieldSpec.Validate (required, min, max, regex, choices and the field type).
+// Bool fields are asked as a Yes/No choice and slices with the choices tag as a checkbox question,
+// other slices are answered as a comma separated list. Fields with the readonly or noedit tag are skipped
+// and fields with the secret tag are asked like AddSecretQuestion.
+//
+// Example:
+//
+//	type Signup struct {
+//		Name string `json:"name" tg:"label:Your name;required;max:64"`
+//		Plan string `json:"plan" tg:"choices:free|pro;help:You can change it later"`
+//	}
+//
+//	specs, err := parser.ParseFieldSpecs(Signup{})
+//	for _, spec := range specs {
+//		q.AddFieldQuestion(spec)
+//	}
+func (q *Questionaire) AddFieldQuestion(spec parser.FieldSpec) *Questionaire {
+	if spec.ReadOnly || spec.NoEdit {
+		return q
+	}
+
+	text := spec.DisplayLabel()
+	if spec.Help != "" {
+		text += "\n" + spec.Help
+	}
+
+	fieldType := spec.Type
+	if fieldType != nil && fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+
+	isList := fieldType != nil && fieldType.Kind() == reflect.Slice
+	if isList && len(spec.Choices) > 0 {
+		builder := button.NewBuilder()
+		for _, choice := range spec.Choices {
+			builder.Row().ChoiceWithData(choice, choice)
+		}
+		q.AddMultipleAnswerQuestion(spec.Key, text, builder.Build(), spec.Validate)
+		q.questions[len(q.questions)-1].Secret = spec.Secret
+		return q
+	}
+	if isList {
+		text += "\n" + ListHintText
+	}
+
+	var choices [][]button.Button
+	if len(spec.Choices) > 0 {
+		builder := button.NewBuilder()
+		for _, choice := range spec.Choices {
+			builder.SingleChoiceWithData(choice, choice)
+		}
+		choices = builder.Build()
+	} else if fieldType != nil && fieldType.Kind() == reflect.Bool {
+		choices = button.QuickChoicesWithData("Yes", "yes", "No", "no")
+	}
+
+	// specs built by hand may have no type to parse the answer into
+	validate := spec.Validate
+	if spec.Type == nil {
+		validate = nil
+	}
+	q.AddQuestion(spec.Key, text, choices, validate)
+	q.questions[len(q.questions)-1].Secret = spec.Secret
+
+	return q
+}
+
 // SetOnDoneHandler sets the completion handler called when all questions have been answered.
 //
 // The handler receives:
@@ -543,6 +658,20 @@ func (q *Questionaire) SetAllowEditAnswers(allow bool) *Questionaire {
 	return q
 }
 
+// SetDeleteAnswers controls whether the text messages the user answered with are deleted
+// together with the questionnaire messages, when it is done or cancelled.
+// Useful for widgets asking a single question in a chat they keep tidy, like EditForm.
+//
+// Example:
+//
+//	q := questionaire.NewBuilder(chatID, manager).
+//		SetDeleteAnswers(true).
+//		AddQuestion("name", "What's your name?", nil, nil)
+func (q *Questionaire) SetDeleteAnswers(delete bool) *Questionaire {
+	q.deleteAnswers = delete
+	return q
+}
+
 // Done is called internally when all questions have been answered.
 // It marshals the answers to JSON and calls the onDoneHandler.
 // This method is typically not called directly by user code.
@@ -558,10 +687,11 @@ func (q *Questionaire) Done(ctx context.Context, b *bot.Bot, update *models.Upda
 		fmt.Println("[Questionaire] error getting result:", err)
 		return
 	}
-	fmt.Println("[Questionaire] result of questionaire:", string(resultByte))
+	fmt.Println("[Questionaire] result of questionaire:", maskedResult(q))
 
 	if q.onDoneHandler == nil {
 		fmt.Println("[Questionaire] no onDoneHandler set, skipping")
+		q.unregister(b)
 		return
 	}
 	result := make(map[string]interface{})
@@ -578,6 +708,8 @@ func (q *Questionaire) Done(ctx context.Context, b *bot.Bot, update *models.Upda
 		return
 	}
 
+	q.unregister(b)
+
 	deleteParams := bot.DeleteMessagesParams{
 		ChatID:     q.chatID,
 		MessageIDs: q.msgIds,
@@ -616,7 +748,7 @@ const (
 //	q.Show(ctx, bot, chatID)
 func (q *Questionaire) Show(ctx context.Context, b *bot.Bot, chatID any) {
 	curQuestion := q.questions[q.currentQuestionIndex]
-	fmt.Println("[question] -> ", q.callbackID, "->", curQuestion)
+	fmt.Println("[question] -> ", q.callbackID, "->", curQuestion.Key)
 
 	if q.manager != nil && q.chatID != nil {
 		q.manager.Add(q.chatID.(int64), q)
@@ -636,16 +768,16 @@ func (q *Questionaire) Show(ctx context.Context, b *bot.Bot, chatID any) {
 		ctx = context.WithValue(ctx, "error", nil)
 	}
 
-	inlineKB := inline.New(b, inline.WithPrefix(
-		fmt.Sprintf("qs_%s_step%d", q.callbackID, q.currentQuestionIndex),
-	))
+	q.register(b)
+	step := q.currentQuestionIndex
+	keyboard := make([][]models.InlineKeyboardButton, 0)
 
 	// Handle different question formats with appropriate UI
 	switch curQuestion.QuestionFormat {
 	case QuestionFormatRadio:
 		// Radio buttons: simple selection without checkbox symbols
 		for _, choiceRow := range curQuestion.Choices {
-			inlineKB.Row()
+			row := make([]models.InlineKeyboardButton, 0, len(choiceRow))
 			for _, choice := range choiceRow {
 				// Check if this choice is selected
 				isSelected := curQuestion.Answer == choice.CallbackData
@@ -655,42 +787,42 @@ func (q *Questionaire) Show(ctx context.Context, b *bot.Bot, chatID any) {
 				} else {
 					buttonText = RadioUnselected + " " + choice.Text
 				}
-				inlineKB.Button(
+				row = append(row, q.button(
 					helper.EscapeTelegramReserved(buttonText),
-					[]byte(choice.CallbackData),
-					q.onInlineKeyboardSelect,
-				)
+					cbCmdSelect, step, curQuestion.choiceIndex(choice.CallbackData),
+				))
 			}
+			keyboard = append(keyboard, row)
 		}
 
 	case QuestionFormatCheck:
 		// Checkbox: show selected and unselected with checkbox symbols
 		// Add selected choices
 		for _, choiceRow := range curQuestion.GetSelectedChoices() {
-			inlineKB.Row()
+			row := make([]models.InlineKeyboardButton, 0, len(choiceRow))
 			for _, choice := range choiceRow {
-				inlineKB.Button(
+				row = append(row, q.button(
 					CheckSelected+" "+helper.EscapeTelegramReserved(choice.Text),
-					[]byte(choice.CallbackData),
-					q.onInlineKeyboardUnSelect,
-				)
+					cbCmdUnselect, step, curQuestion.choiceIndex(choice.CallbackData),
+				))
 			}
+			keyboard = append(keyboard, row)
 		}
 
 		// Add unselected choices
 		for _, choiceRow := range curQuestion.GetUnselectedChoices() {
-			inlineKB.Row()
+			row := make([]models.InlineKeyboardButton, 0, len(choiceRow))
 			for _, choice := range choiceRow {
-				inlineKB.Button(
+				row = append(row, q.button(
 					CheckUnselected+" "+helper.EscapeTelegramReserved(choice.Text),
-					[]byte(choice.CallbackData),
-					q.onInlineKeyboardSelect,
-				)
+					cbCmdSelect, step, curQuestion.choiceIndex(choice.CallbackData),
+				))
 			}
+			keyboard = append(keyboard, row)
 		}
 
 		// Add "Done" button for checkbox questions
-		inlineKB.Row().Button(DoneButtonText, []byte("cmd_done"), q.onDoneChoosing)
+		keyboard = append(keyboard, []models.InlineKeyboardButton{q.button(DoneButtonText, cbCmdDone, step)})
 
 	case QuestionFormatText:
 		// Text input: no buttons needed, user will type response
@@ -698,11 +830,12 @@ func (q *Questionaire) Show(ctx context.Context, b *bot.Bot, chatID any) {
 	}
 
 	if q.onCancelHandler != nil {
-		inlineKB.Row()
-		inlineKB.Button(CancelButtonText, []byte("cmd_cancel"), q.onCancel)
+		keyboard = append(keyboard, []models.InlineKeyboardButton{q.button(CancelButtonText, cbCmdCancel)})
 	}
 
-	params.ReplyMarkup = inlineKB
+	if len(keyboard) > 0 {
+		params.ReplyMarkup = &models.InlineKeyboardMarkup{InlineKeyboard: keyboard}
+	}
 
 	m, err := b.SendMessage(ctx, params)
 
@@ -803,6 +936,7 @@ func (q *Questionaire) onCancel(ctx context.Context, b *bot.Bot, mes models.Mayb
 			MessageIDs: q.msgIds,
 		}
 		b.DeleteMessages(ctx, &deleteParams)
+		q.unregister(b)
 		q.onCancelHandler()
 	}
 }
@@ -883,3 +1017,23 @@ func GetResultByte(q *Questionaire) ([]byte, error) {
 	data, err := json.Marshal(q.GetAnswers())
 	return data, err
 }
+
+// maskedResult returns the answers as JSON for logs, the answers of secret questions masked
+func maskedResult(q *Questionaire) string {
+	answers := q.GetAnswers()
+	for _, question := range q.questions {
+		if _, ok := answers[question.Key]; ok && question.Secret {
+			answers[question.Key] = SecretAnswerText
+		}
+	}
+	data, err := json.Marshal(answers)
+	if err != nil {
+		return err.Error()
+	}
+	return string(data)
+}
+
+// isSecret reports whether the current question is a secret question
+func (q *Questionaire) isSecret() bool {
+	return q.currentQuestionIndex < len(q.questions) && q.questions[q.currentQuestionIndex].Secret
+}
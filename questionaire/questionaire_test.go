@@ -0,0 +1,68 @@
+package questionaire
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/jkevinp/tgui/internal/testbot"
+	"github.com/jkevinp/tgui/router"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecretQuestion(t *testing.T) {
+	b, server := testbot.New(t)
+
+	var answers map[string]interface{}
+	manager := NewManager()
+	q := NewBuilder(int64(42), manager).
+		AddQuestion("name", "Your name?", nil, nil).
+		AddSecretQuestion("token", "Your token?", nil, nil).
+		SetOnDoneHandler(func(ctx context.Context, b *bot.Bot, chatID any, result map[string]interface{}) error {
+			answers = result
+			return nil
+		})
+	ctx := context.Background()
+	q.Show(ctx, b, int64(42))
+
+	answer := func(id int, text string) {
+		manager.HandleMessage(ctx, b, &models.Update{Message: &models.Message{ID: id, Chat: models.Chat{ID: 42}, Text: text}})
+	}
+
+	answer(7, "Ann")
+	assert.NotContains(t, server.Methods(), "deleteMessage", "a plain answer is kept")
+
+	server.Reset()
+	answer(8, "s3cr3t")
+	assert.Equal(t, "deleteMessage", server.Methods()[0], "the secret is deleted at once")
+	assert.Contains(t, server.Requests()[0].Body, "name=\"message_id\"\r\n\r\n8\r\n")
+	for _, body := range server.Bodies("") {
+		assert.NotContains(t, body, "s3cr3t")
+	}
+
+	assert.Equal(t, SecretAnswerText, q.questions[1].GetDisplayAnswer())
+	assert.Equal(t, `{"name":"Ann","token":"••••••"}`, maskedResult(q))
+	assert.Equal(t, "s3cr3t", answers["token"], "the done handler gets the secret")
+}
+
+func TestHandlerCountAcrossShows(t *testing.T) {
+	b, _ := testbot.New(t)
+	r := router.New(b)
+	manager := NewManager()
+	ctx := context.Background()
+
+	q := NewBuilder(int64(42), manager).
+		SetRouter(r).
+		AddQuestion("name", "Your name?", nil, nil).
+		SetOnDoneHandler(func(ctx context.Context, b *bot.Bot, chatID any, result map[string]interface{}) error {
+			return nil
+		})
+	for i := 0; i < 100; i++ {
+		q.Show(ctx, b, int64(42))
+	}
+	assert.Equal(t, 1, r.Len(), "one handler per questionnaire")
+
+	manager.HandleMessage(ctx, b, &models.Update{Message: &models.Message{ID: 7, Chat: models.Chat{ID: 42}, Text: "Ann"}})
+	assert.Equal(t, 0, r.Len())
+}
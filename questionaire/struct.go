@@ -0,0 +1,170 @@
+package questionaire
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/go-telegram/bot"
+	"github.com/jkevinp/tgui/parser"
+)
+
+// onDoneStructHandlerFunc is called with a pointer to a new instance of the struct given to NewFromStruct.
+type onDoneStructHandlerFunc func(ctx context.Context, b *bot.Bot, chatID any, result any) error
+
+// NewFromStruct creates a questionnaire asking for the fields of a tagged struct.
+//
+// The fields are read with parser.ParseFieldSpecs, keyed by their json names, and asked in the
+// order of the order tag and then declaration order, see AddFieldQuestion for how a field is asked.
+// Fields of types the parser can't read, such as nested structs and maps, and fields with the
+// readonly or noedit tag are not asked and keep the value of target.
+//
+// Parameters:
+//   - chatID: The Telegram chat ID where this questionnaire will run
+//   - manager: Optional Manager instance to handle text message routing (can be nil)
+//   - target: The struct, or a pointer to it, providing the fields and the values of the fields not asked
+//
+// Returns an error wrapping parser.ErrInvalidTag if a tg tag is malformed, or an error for a nil pointer.
+//
+// Example:
+//
+//	type Signup struct {
+//		Name      string   `json:"name" tg:"label:Your name;required;max:64"`
+//		Age       int      `json:"age" tg:"min:18"`
+//		Plan      string   `json:"plan" tg:"choices:free|pro"`
+//		Interests []string `json:"interests" tg:"choices:tech|sports|music"`
+//	}
+//
+//	q, err := questionaire.NewFromStruct(chatID, manager, Signup{Plan: "free"})
+//	if err != nil {
+//		return err
+//	}
+//	q.SetOnDoneStructHandler(func(ctx context.Context, b *bot.Bot, chatID any, result any) error {
+//		signup := result.(*Signup)
+//		...
+//	})
+func NewFromStruct(chatID any, manager *Manager, target any) (*Questionaire, error) {
+	specs, err := parser.ParseFieldSpecs(target)
+	if err != nil {
+		return nil, err
+	}
+
+	value := reflect.ValueOf(target)
+	if value.Kind() == reflect.Ptr && value.IsNil() {
+		return nil, fmt.Errorf("target must not be a nil pointer")
+	}
+	if value.Kind() == reflect.Ptr {
+		value = value.Elem()
+	}
+
+	q := NewBuilder(chatID, manager)
+	q.target = reflect.New(value.Type()).Elem()
+	q.target.Set(value)
+
+	for _, spec := range specs {
+		if spec.ReadOnly || spec.NoEdit || !parser.CanParse(spec.Type) {
+			continue
+		}
+		q.AddFieldQuestion(spec)
+		q.fieldSpecs = append(q.fieldSpecs, spec)
+	}
+
+	return q, nil
+}
+
+// SetOnDoneStructHandler sets the completion handler of a questionnaire created with NewFromStruct.
+//
+// The handler receives a pointer to a new instance of the struct, holding the decoded answers and
+// the values of the fields not asked. The answers don't modify the struct given to NewFromStruct, but the
+// result is a shallow copy: maps, slices and pointers that weren't answered are shared with that struct.
+// If the answers can't be decoded the error is sent to the user, like an error of the handler.
+//
+// Example:
+//
+//	q.SetOnDoneStructHandler(func(ctx context.Context, b *bot.Bot, chatID any, result any) error {
+//		signup := result.(*Signup)
+//		_, err := b.SendMessage(ctx, &bot.SendMessageParams{
+//			ChatID: chatID,
+//			Text:   "Welcome, " + signup.Name,
+//		})
+//		return err
+//	})
+func (q *Questionaire) SetOnDoneStructHandler(handler onDoneStructHandlerFunc) *Questionaire {
+	q.onDoneHandler = func(ctx context.Context, b *bot.Bot, chatID any, answers map[string]interface{}) error {
+		if !q.target.IsValid() {
+			return fmt.Errorf("questionaire was not created with NewFromStruct")
+		}
+
+		result := reflect.New(q.target.Type())
+		result.Elem().Set(q.target)
+		if err := q.DecodeAnswers(answers, result.Interface()); err != nil {
+			return err
+		}
+		return handler(ctx, b, chatID, result.Interface())
+	}
+	return q
+}
+
+// DecodeAnswers sets the fields of target, a pointer to the struct given to NewFromStruct, to the answers.
+//
+// The answers are the map given to the done handler or returned by GetAnswers. Text and radio answers
+// are parsed into the field type, checkbox answers into the elements of a slice field.
+// Fields without an answer are left unchanged.
+func (q *Questionaire) DecodeAnswers(answers map[string]interface{}, target any) error {
+	v := reflect.ValueOf(target)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("target must be a non-nil pointer to a struct")
+	}
+	v = v.Elem()
+
+	for _, spec := range q.fieldSpecs {
+		answer, ok := answers[spec.Key]
+		if !ok {
+			continue
+		}
+
+		value, err := decodeAnswer(spec, answer)
+		if err != nil {
+			return fmt.Errorf("%s: %w", spec.DisplayLabel(), err)
+		}
+		v.FieldByIndex(spec.Index).Set(value)
+	}
+
+	return nil
+}
+
+// decodeAnswer parses a string answer, or the selected choices of a checkbox question, into the field type.
+// The choices are []string from GetAnswers or []interface{} after the JSON round trip in Done.
+func decodeAnswer(spec parser.FieldSpec, answer interface{}) (reflect.Value, error) {
+	switch answer := answer.(type) {
+	case string:
+		return spec.Parse(answer)
+	case []string:
+		return decodeChoices(spec.Type, answer)
+	case []interface{}:
+		choices := make([]string, 0, len(answer))
+		for _, choice := range answer {
+			choices = append(choices, fmt.Sprint(choice))
+		}
+		return decodeChoices(spec.Type, choices)
+	}
+	return reflect.Value{}, fmt.Errorf("unexpected answer %T", answer)
+}
+
+// decodeChoices parses each selected choice into an element of the slice type t.
+// The choices are not joined with commas, so a choice may contain one.
+func decodeChoices(t reflect.Type, choices []string) (reflect.Value, error) {
+	if t.Kind() != reflect.Slice {
+		return reflect.Value{}, fmt.Errorf("%s can't hold multiple answers", t)
+	}
+
+	list := reflect.MakeSlice(t, 0, len(choices))
+	for _, choice := range choices {
+		elem, err := parser.ParseValue(choice, t.Elem())
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		list = reflect.Append(list, elem)
+	}
+	return list, nil
+}
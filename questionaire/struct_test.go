@@ -0,0 +1,102 @@
+package questionaire
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/stretchr/testify/assert"
+)
+
+type signup struct {
+	ID        int       `json:"id" tg:"readonly"`
+	Name      string    `json:"name" tg:"label:Your name;required;max:10"`
+	Age       *int      `json:"age" tg:"min:18"`
+	Plan      string    `json:"plan" tg:"choices:free|pro"`
+	Interests []string  `json:"interests" tg:"choices:tech|sports|music"`
+	Emails    []string  `json:"emails"`
+	News      bool      `json:"news"`
+	Start     time.Time `json:"start"`
+	Extra     map[string]string
+}
+
+func TestNewFromStruct(t *testing.T) {
+	q, err := NewFromStruct(int64(1), nil, signup{ID: 7, Plan: "free"})
+	assert.NoError(t, err)
+
+	formats := map[string]QuestionFormat{}
+	var keys []string
+	for _, question := range q.questions {
+		keys = append(keys, question.Key)
+		formats[question.Key] = question.QuestionFormat
+	}
+	assert.Equal(t, []string{"name", "age", "plan", "interests", "emails", "news", "start"}, keys, "readonly and unsupported fields are not asked")
+	assert.Equal(t, QuestionFormatText, formats["name"])
+	assert.Equal(t, QuestionFormatRadio, formats["plan"])
+	assert.Equal(t, QuestionFormatCheck, formats["interests"])
+	assert.Equal(t, QuestionFormatText, formats["emails"])
+	assert.Equal(t, QuestionFormatRadio, formats["news"])
+
+	assert.Error(t, q.questions[0].Validate("A very long name"))
+	assert.Error(t, q.questions[1].Validate("17"))
+	assert.Error(t, q.questions[3].Validate("cooking"))
+
+	_, err = NewFromStruct(int64(1), nil, struct {
+		Name string `tg:"lable:Name"`
+	}{})
+	assert.Error(t, err)
+
+	_, err = NewFromStruct(int64(1), nil, (*signup)(nil))
+	assert.Error(t, err, "a nil pointer has no values to keep")
+
+	q, err = NewFromStruct(int64(1), nil, struct {
+		Scopes []string `json:"scopes" tg:"choices:read|write;secret"`
+	}{})
+	assert.NoError(t, err)
+	assert.Equal(t, QuestionFormatCheck, q.questions[0].QuestionFormat)
+	assert.True(t, q.questions[0].Secret, "a secret list with choices is masked too")
+}
+
+func TestDecodeAnswers(t *testing.T) {
+	target := signup{ID: 7, Extra: map[string]string{"a": "b"}}
+	q, err := NewFromStruct(int64(1), nil, &target)
+	assert.NoError(t, err)
+
+	answers := map[string]interface{}{
+		"name":      "Ann",
+		"age":       "30",
+		"plan":      "pro",
+		"interests": []string{"tech", "music"},
+		"emails":    "ann@example.com, a@example.com",
+		"news":      "yes",
+		"start":     "2024-05-01",
+	}
+	// Done passes the answers through JSON
+	data, err := json.Marshal(answers)
+	assert.NoError(t, err)
+	answers = map[string]interface{}{}
+	assert.NoError(t, json.Unmarshal(data, &answers))
+
+	var got *signup
+	q.SetOnDoneStructHandler(func(_ context.Context, _ *bot.Bot, _ any, result any) error {
+		got = result.(*signup)
+		return nil
+	})
+	assert.NoError(t, q.onDoneHandler(context.Background(), nil, int64(1), answers))
+
+	assert.Equal(t, 7, got.ID, "fields not asked keep the target value")
+	assert.Equal(t, "Ann", got.Name)
+	assert.Equal(t, 30, *got.Age)
+	assert.Equal(t, "pro", got.Plan)
+	assert.Equal(t, []string{"tech", "music"}, got.Interests)
+	assert.Equal(t, []string{"ann@example.com", "a@example.com"}, got.Emails)
+	assert.True(t, got.News)
+	assert.Equal(t, time.Date(2024, 5, 1, 0, 0, 0, 0, time.Local), got.Start)
+	assert.Equal(t, map[string]string{"a": "b"}, got.Extra)
+	assert.Empty(t, target.Name, "the target is not modified")
+
+	answers["age"] = "old"
+	assert.Error(t, q.onDoneHandler(context.Background(), nil, int64(1), answers))
+}
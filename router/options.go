@@ -0,0 +1,24 @@
+package router
+
+type Option func(r *Router)
+
+// WithPrefix sets the prefix of the router's bot handler (default: "~")
+func WithPrefix(s string) Option {
+	return func(r *Router) {
+		r.prefix = s
+	}
+}
+
+// WithExpiredText sets the text shown for buttons of removed or unknown widgets
+func WithExpiredText(s string) Option {
+	return func(r *Router) {
+		r.expiredText = s
+	}
+}
+
+// OnError sets the error handler
+func OnError(f OnErrorHandler) Option {
+	return func(r *Router) {
+		r.onError = f
+	}
+}
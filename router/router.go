@@ -0,0 +1,176 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+const (
+	defaultPrefix      = "~"
+	defaultExpiredText = "This button has expired"
+
+	// idSeparator ends the widget ID in the callback data, the widget's own data follows it
+	idSeparator = ":"
+)
+
+type OnErrorHandler func(err error)
+
+// Router owns a single bot callback handler and dispatches the callback queries to widgets by a short ID.
+// The callback data of a routed widget is the router prefix, the widget ID and the widget's own data, e.g. "~k3Dp1:5".
+type Router struct {
+	prefix      string
+	session     string
+	expiredText string
+	onError     OnErrorHandler
+
+	mu      sync.RWMutex
+	routes  map[string]bot.HandlerFunc
+	counter uint64
+
+	callbackHandlerID string
+}
+
+func New(b *bot.Bot, opts ...Option) *Router {
+	r := &Router{
+		prefix:      defaultPrefix,
+		session:     bot.RandomString(3),
+		expiredText: defaultExpiredText,
+		onError:     defaultOnError,
+		routes:      make(map[string]bot.HandlerFunc),
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	r.callbackHandlerID = b.RegisterHandler(bot.HandlerTypeCallbackQueryData, r.prefix, bot.MatchTypePrefix, r.callback)
+
+	return r
+}
+
+// Prefix returns the prefix of the callback data of all routed widgets
+func (r *Router) Prefix() string {
+	return r.prefix
+}
+
+// GetCallbackHandlerID returns the ID of the bot handler registered for the router
+func (r *Router) GetCallbackHandlerID() string {
+	return r.callbackHandlerID
+}
+
+/*
+Handle adds a widget handler and returns its ID and the prefix for the widget's callback data.
+The namespace is a short tag of the widget type, e.g. "pg" for a paginator; it must not contain ":".
+The ID contains a random part per router, so buttons sent before a restart are reported as expired.
+*/
+func (r *Router) Handle(namespace string, handler bot.HandlerFunc) (id string, prefix string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.counter++
+	id = r.session + namespace + strconv.FormatUint(r.counter, 36)
+	r.routes[id] = handler
+
+	return id, r.prefix + id + idSeparator
+}
+
+// Remove removes the widget handler, its buttons are reported as expired afterwards
+func (r *Router) Remove(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.routes, id)
+}
+
+// Len returns the number of widget handlers
+func (r *Router) Len() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return len(r.routes)
+}
+
+// Unregister removes the router's handler from the bot
+func (r *Router) Unregister(b *bot.Bot) {
+	b.UnregisterHandler(r.callbackHandlerID)
+}
+
+func defaultOnError(err error) {
+	log.Printf("[TG-UI-ROUTER] [ERROR] %s", err)
+}
+
+func (r *Router) callbackAnswer(ctx context.Context, b *bot.Bot, callbackQuery *models.CallbackQuery, text string) {
+	ok, err := b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+		CallbackQueryID: callbackQuery.ID,
+		Text:            text,
+	})
+	if err != nil {
+		r.onError(err)
+		return
+	}
+	if !ok {
+		r.onError(fmt.Errorf("callback answer failed"))
+	}
+}
+
+// pendingAnswer answers the callback query of a routed update once.
+type pendingAnswer struct {
+	once          sync.Once
+	router        *Router
+	b             *bot.Bot
+	callbackQuery *models.CallbackQuery
+}
+
+func (p *pendingAnswer) answer(ctx context.Context, text string) {
+	p.once.Do(func() {
+		p.router.callbackAnswer(ctx, p.b, p.callbackQuery, text)
+	})
+}
+
+type pendingAnswerKey struct{}
+
+/*
+Answer answers the callback query of the routed update handled with ctx, e.g. with an error text for the user.
+Only the first answer of a query is sent; the router answers with an empty text after the handler if it wasn't answered.
+It returns false for an update that wasn't routed, so the widget answers the query itself.
+*/
+func Answer(ctx context.Context, text string) bool {
+	pending, ok := ctx.Value(pendingAnswerKey{}).(*pendingAnswer)
+	if !ok {
+		return false
+	}
+	pending.answer(ctx, text)
+	return true
+}
+
+// callback dispatches the callback query to the widget and makes sure the query is answered exactly once.
+// A panic in the widget handler, e.g. on malformed callback data, is reported to the error handler.
+func (r *Router) callback(ctx context.Context, b *bot.Bot, update *models.Update) {
+	id, _, found := strings.Cut(strings.TrimPrefix(update.CallbackQuery.Data, r.prefix), idSeparator)
+
+	r.mu.RLock()
+	handler, ok := r.routes[id]
+	r.mu.RUnlock()
+
+	if !found || !ok {
+		r.callbackAnswer(ctx, b, update.CallbackQuery, r.expiredText)
+		return
+	}
+
+	pending := &pendingAnswer{router: r, b: b, callbackQuery: update.CallbackQuery}
+	defer func() {
+		if rec := recover(); rec != nil {
+			r.onError(fmt.Errorf("panic in handler %s: %v", id, rec))
+		}
+		pending.answer(ctx, "")
+	}()
+
+	handler(context.WithValue(ctx, pendingAnswerKey{}, pending), b, update)
+}
@@ -0,0 +1,93 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/jkevinp/tgui/internal/testbot"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestBot returns a bot backed by a fake API server, and the answered callback queries.
+func newTestBot(t *testing.T) (*bot.Bot, func() []string) {
+	b, server := testbot.New(t)
+	return b, func() []string { return server.Bodies("answerCallbackQuery") }
+}
+
+func callbackUpdate(data string) *models.Update {
+	return &models.Update{CallbackQuery: &models.CallbackQuery{ID: "1", Data: data}}
+}
+
+func TestRouterDispatch(t *testing.T) {
+	b, answers := newTestBot(t)
+	r := New(b)
+
+	var got []string
+	var prefix string
+	id, prefix := r.Handle("pg", func(ctx context.Context, b *bot.Bot, update *models.Update) {
+		got = append(got, strings.TrimPrefix(update.CallbackQuery.Data, prefix))
+	})
+	_, other := r.Handle("pg", func(ctx context.Context, b *bot.Bot, update *models.Update) {})
+	assert.NotEqual(t, prefix, other)
+	assert.True(t, strings.HasPrefix(prefix, r.Prefix()))
+	assert.Equal(t, 2, r.Len())
+
+	r.callback(context.Background(), b, callbackUpdate(prefix+"next"))
+	assert.Equal(t, []string{"next"}, got)
+	assert.Len(t, answers(), 1)
+	assert.NotContains(t, answers()[0], defaultExpiredText)
+
+	r.Remove(id)
+	r.callback(context.Background(), b, callbackUpdate(prefix+"next"))
+	assert.Equal(t, []string{"next"}, got, "removed handlers aren't called")
+	assert.Contains(t, answers()[1], defaultExpiredText)
+}
+
+func TestRouterExpired(t *testing.T) {
+	b, answers := newTestBot(t)
+	r := New(b, WithExpiredText("Gone"))
+
+	r.callback(context.Background(), b, callbackUpdate(r.Prefix()+"unknown:1"))
+	r.callback(context.Background(), b, callbackUpdate(r.Prefix()+"malformed"))
+	assert.Len(t, answers(), 2)
+	for _, answer := range answers() {
+		assert.Contains(t, answer, "Gone")
+	}
+}
+
+func TestRouterRecover(t *testing.T) {
+	b, answers := newTestBot(t)
+	var errs []error
+	r := New(b, OnError(func(err error) { errs = append(errs, err) }))
+
+	_, prefix := r.Handle("dp", func(ctx context.Context, b *bot.Bot, update *models.Update) {
+		panic(errors.New("invalid data format"))
+	})
+
+	assert.NotPanics(t, func() {
+		r.callback(context.Background(), b, callbackUpdate(prefix+"bad"))
+	})
+	assert.Len(t, answers(), 1, "the query is answered after a panic")
+	assert.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "invalid data format")
+}
+
+func TestRouterAnswer(t *testing.T) {
+	b, answers := newTestBot(t)
+	r := New(b)
+
+	assert.False(t, Answer(context.Background(), "not routed"))
+
+	_, prefix := r.Handle("pg", func(ctx context.Context, b *bot.Bot, update *models.Update) {
+		assert.True(t, Answer(ctx, "Invalid page"))
+		assert.True(t, Answer(ctx, "second answer"))
+	})
+	r.callback(context.Background(), b, callbackUpdate(prefix+"-1"))
+
+	assert.Len(t, answers(), 1, "a query is answered once")
+	assert.Contains(t, answers()[0], "Invalid page")
+}
@@ -7,11 +7,17 @@ import (
 
 	"github.com/go-telegram/bot"
 	"github.com/go-telegram/bot/models"
+	"github.com/jkevinp/tgui/callbackdata"
+	"github.com/jkevinp/tgui/router"
 )
 
-func (s *Slider) callbackAnswer(ctx context.Context, b *bot.Bot, callbackQuery *models.CallbackQuery) {
+func (s *Slider) callbackAnswer(ctx context.Context, b *bot.Bot, callbackQuery *models.CallbackQuery, text string) {
+	if router.Answer(ctx, text) {
+		return
+	}
 	ok, err := b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
 		CallbackQueryID: callbackQuery.ID,
+		Text:            text,
 	})
 	if err != nil {
 		s.onError(err)
@@ -23,9 +29,16 @@ func (s *Slider) callbackAnswer(ctx context.Context, b *bot.Bot, callbackQuery *
 }
 
 func (s *Slider) callback(ctx context.Context, b *bot.Bot, update *models.Update) {
-	cmd := strings.TrimPrefix(update.CallbackQuery.Data, s.prefix)
+	data, errVerify := s.signer.Verify(update.CallbackQuery.Data)
+	if errVerify != nil {
+		s.onError(fmt.Errorf("%w, %s", errVerify, update.CallbackQuery.Data))
+		s.callbackAnswer(ctx, b, update.CallbackQuery, callbackdata.InvalidButtonText)
+		return
+	}
+	cmd := strings.TrimPrefix(data, s.prefix)
 
 	switch cmd {
+	case cmdNop:
 	case cmdPrev:
 		s.current--
 		if s.current < 0 {
@@ -38,7 +51,7 @@ func (s *Slider) callback(ctx context.Context, b *bot.Bot, update *models.Update
 		}
 	case cmdSelect:
 		if s.deleteOnSelect {
-			b.UnregisterHandler(s.callbackHandlerID)
+			s.unregister(b)
 
 			_, errDelete := b.DeleteMessage(ctx, &bot.DeleteMessageParams{
 				ChatID:    update.CallbackQuery.Message.Message.Chat.ID,
@@ -49,11 +62,11 @@ func (s *Slider) callback(ctx context.Context, b *bot.Bot, update *models.Update
 			}
 		}
 		s.onSelect(ctx, b, update.CallbackQuery.Message, s.current)
-		s.callbackAnswer(ctx, b, update.CallbackQuery)
+		s.callbackAnswer(ctx, b, update.CallbackQuery, "")
 		return
 	case cmdCancel:
 		if s.deleteOnCancel {
-			b.UnregisterHandler(s.callbackHandlerID)
+			s.unregister(b)
 
 			_, errDelete := b.DeleteMessage(ctx, &bot.DeleteMessageParams{
 				ChatID:    update.CallbackQuery.Message.Message.Chat.ID,
@@ -64,7 +77,11 @@ func (s *Slider) callback(ctx context.Context, b *bot.Bot, update *models.Update
 			}
 		}
 		s.onCancel(ctx, b, update.CallbackQuery.Message)
-		s.callbackAnswer(ctx, b, update.CallbackQuery)
+		s.callbackAnswer(ctx, b, update.CallbackQuery, "")
+		return
+	default:
+		s.onError(fmt.Errorf("wrong callback data, %s", update.CallbackQuery.Data))
+		s.callbackAnswer(ctx, b, update.CallbackQuery, callbackdata.InvalidButtonText)
 		return
 	}
 
@@ -94,5 +111,5 @@ func (s *Slider) callback(ctx context.Context, b *bot.Bot, update *models.Update
 		s.onError(errEdit)
 	}
 
-	s.callbackAnswer(ctx, b, update.CallbackQuery)
+	s.callbackAnswer(ctx, b, update.CallbackQuery, "")
 }
@@ -10,19 +10,19 @@ func (s *Slider) buildKeyboard() models.InlineKeyboardMarkup {
 	kb := models.InlineKeyboardMarkup{
 		InlineKeyboard: [][]models.InlineKeyboardButton{
 			{
-				{Text: "\u00AB", CallbackData: s.prefix + cmdPrev},
-				{Text: strconv.Itoa(s.current+1) + "/" + strconv.Itoa(len(s.slides)), CallbackData: s.prefix + cmdNop},
-				{Text: "\u00BB", CallbackData: s.prefix + cmdNext},
+				{Text: "\u00AB", CallbackData: s.callbackData(cmdPrev)},
+				{Text: strconv.Itoa(s.current+1) + "/" + strconv.Itoa(len(s.slides)), CallbackData: s.callbackData(cmdNop)},
+				{Text: "\u00BB", CallbackData: s.callbackData(cmdNext)},
 			},
 		},
 	}
 
 	var row []models.InlineKeyboardButton
 	if s.onSelect != nil {
-		row = append(row, models.InlineKeyboardButton{Text: s.selectButtonText, CallbackData: s.prefix + cmdSelect})
+		row = append(row, models.InlineKeyboardButton{Text: s.selectButtonText, CallbackData: s.callbackData(cmdSelect)})
 	}
 	if s.onCancel != nil {
-		row = append(row, models.InlineKeyboardButton{Text: s.cancelButtonText, CallbackData: s.prefix + cmdCancel})
+		row = append(row, models.InlineKeyboardButton{Text: s.cancelButtonText, CallbackData: s.callbackData(cmdCancel)})
 	}
 
 	if len(row) > 0 {
@@ -31,3 +31,8 @@ func (s *Slider) buildKeyboard() models.InlineKeyboardMarkup {
 
 	return kb
 }
+
+// callbackData returns the callback data of the command, signed if a signing key is set
+func (s *Slider) callbackData(cmd string) string {
+	return s.signer.Sign(s.prefix + cmd)
+}
@@ -1,5 +1,10 @@
 package slider
 
+import (
+	"github.com/jkevinp/tgui/callbackdata"
+	"github.com/jkevinp/tgui/router"
+)
+
 type Option func(s *Slider)
 
 // OnSelect is a callback function that is called when the user selects a slide
@@ -47,3 +52,18 @@ func NoDeleteAfterCancel() Option {
 		s.deleteOnCancel = false
 	}
 }
+
+// WithRouter dispatches the widget's callbacks through the router instead of registering its own bot handler.
+// The router assigns the prefix, so WithPrefix has no effect.
+func WithRouter(r *router.Router) Option {
+	return func(s *Slider) {
+		s.router = r
+	}
+}
+
+// WithSigningKey is a slider option that signs the callback data with the key, clicks with tampered data are rejected.
+func WithSigningKey(key []byte) Option {
+	return func(s *Slider) {
+		s.signer = callbackdata.NewSigner(key)
+	}
+}
@@ -7,6 +7,8 @@ import (
 
 	"github.com/go-telegram/bot"
 	"github.com/go-telegram/bot/models"
+	"github.com/jkevinp/tgui/callbackdata"
+	"github.com/jkevinp/tgui/router"
 )
 
 type OnSelectFunc func(ctx context.Context, b *bot.Bot, message models.MaybeInaccessibleMessage, item int)
@@ -39,6 +41,8 @@ type Slider struct {
 
 	deleteOnSelect bool
 	deleteOnCancel bool
+	router         *router.Router
+	signer         *callbackdata.Signer
 
 	current           int
 	callbackHandlerID string
@@ -57,11 +61,24 @@ func New(b *bot.Bot, slides []Slide, opts ...Option) *Slider {
 		opt(s)
 	}
 
-	s.callbackHandlerID = b.RegisterHandler(bot.HandlerTypeCallbackQueryData, s.prefix, bot.MatchTypePrefix, s.callback)
+	if s.router != nil {
+		s.callbackHandlerID, s.prefix = s.router.Handle("sl", s.callback)
+	} else {
+		s.callbackHandlerID = b.RegisterHandler(bot.HandlerTypeCallbackQueryData, s.prefix, bot.MatchTypePrefix, s.callback)
+	}
 
 	return s
 }
 
+// unregister removes the widget's handler from the router or the bot
+func (s *Slider) unregister(b *bot.Bot) {
+	if s.router != nil {
+		s.router.Remove(s.callbackHandlerID)
+		return
+	}
+	b.UnregisterHandler(s.callbackHandlerID)
+}
+
 // Prefix returns the prefix of the widget
 func (s *Slider) Prefix() string {
 	return s.prefix